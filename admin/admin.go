@@ -0,0 +1,127 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package admin provides an HTTP API to change a test in execution
+package admin
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/jjmrocha/beast/control"
+	"github.com/jjmrocha/beast/report"
+)
+
+// Target is the test in execution controlled by the API
+type Target interface {
+	Pause()
+	Resume()
+	SetWorkers(n int) error
+	SetRate(rate float64) error
+	Status() control.Status
+}
+
+// Counters provides the live counters of the test in execution
+type Counters interface {
+	Snapshot() report.Snapshot
+}
+
+type status struct {
+	control.Status
+	Stats report.Snapshot `json:"stats"`
+}
+
+// Handler returns the http.Handler implementing the API:
+// POST /pause, POST /resume, POST /workers?value=<n>, POST /rate?value=<r> and GET /stats
+func Handler(target Target, counters Counters) http.Handler {
+	writeStatus := func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status{
+			Status: target.Status(),
+			Stats:  counters.Snapshot(),
+		})
+	}
+
+	post := func(action func(r *http.Request) error) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			if err := action(r); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			writeStatus(w)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pause", post(func(r *http.Request) error {
+		target.Pause()
+		return nil
+	}))
+	mux.HandleFunc("/resume", post(func(r *http.Request) error {
+		target.Resume()
+		return nil
+	}))
+	mux.HandleFunc("/workers", post(func(r *http.Request) error {
+		n, err := strconv.Atoi(r.URL.Query().Get("value"))
+		if err != nil {
+			return control.ErrInvalidValue
+		}
+
+		return target.SetWorkers(n)
+	}))
+	mux.HandleFunc("/rate", post(func(r *http.Request) error {
+		rate, err := strconv.ParseFloat(r.URL.Query().Get("value"), 64)
+		if err != nil {
+			return control.ErrInvalidValue
+		}
+
+		return target.SetRate(rate)
+	}))
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		writeStatus(w)
+	})
+	return mux
+}
+
+// Start listens on the address and serves the API in background
+func Start(address string, target Target, counters Counters) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := http.Serve(listener, Handler(target, counters)); err != nil {
+			log.Printf("Admin API stopped: %v\n", err)
+		}
+	}()
+
+	return nil
+}
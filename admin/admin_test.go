@@ -0,0 +1,92 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jjmrocha/beast/control"
+	"github.com/jjmrocha/beast/report"
+)
+
+type mockedTarget struct {
+	status control.Status
+}
+
+func (m *mockedTarget) Pause()  { m.status.Paused = true }
+func (m *mockedTarget) Resume() { m.status.Paused = false }
+
+func (m *mockedTarget) SetWorkers(n int) error {
+	if n < 0 {
+		return control.ErrInvalidValue
+	}
+
+	m.status.Workers = n
+	return nil
+}
+
+func (m *mockedTarget) SetRate(rate float64) error {
+	if rate <= 0 {
+		return control.ErrInvalidValue
+	}
+
+	m.status.Rate = rate
+	return nil
+}
+
+func (m *mockedTarget) Status() control.Status { return m.status }
+
+type mockedCounters struct{}
+
+func (mockedCounters) Snapshot() report.Snapshot { return report.Snapshot{Requests: 10} }
+
+func TestHandler(t *testing.T) {
+	// given
+	target := &mockedTarget{}
+	handler := Handler(target, mockedCounters{})
+	var tests = []struct {
+		method   string
+		path     string
+		code     int
+		expected control.Status
+	}{
+		{http.MethodPost, "/pause", http.StatusOK, control.Status{Paused: true}},
+		{http.MethodPost, "/workers?value=5", http.StatusOK, control.Status{Paused: true, Workers: 5}},
+		{http.MethodPost, "/workers?value=x", http.StatusBadRequest, control.Status{Paused: true, Workers: 5}},
+		{http.MethodPost, "/resume", http.StatusOK, control.Status{Workers: 5}},
+		{http.MethodPost, "/rate?value=2.5", http.StatusOK, control.Status{Workers: 5, Rate: 2.5}},
+		{http.MethodPost, "/rate?value=0", http.StatusBadRequest, control.Status{Workers: 5, Rate: 2.5}},
+		{http.MethodGet, "/pause", http.StatusMethodNotAllowed, control.Status{Workers: 5, Rate: 2.5}},
+		{http.MethodGet, "/stats", http.StatusOK, control.Status{Workers: 5, Rate: 2.5}},
+	}
+	// then
+	for _, test := range tests {
+		// when
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(test.method, test.path, nil))
+		// then
+		if recorder.Code != test.code {
+			t.Errorf("%v %v: got status %v expected %v", test.method, test.path, recorder.Code, test.code)
+		}
+
+		if target.status != test.expected {
+			t.Errorf("%v %v: got %v expected %v", test.method, test.path, target.status, test.expected)
+		}
+	}
+}
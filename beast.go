@@ -18,9 +18,16 @@ package main
 
 import (
 	"flag"
+	"log"
+	"math"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jjmrocha/beast/cmd"
+	"github.com/jjmrocha/beast/control"
+	"github.com/jjmrocha/beast/report"
 )
 
 func main() {
@@ -36,6 +43,12 @@ func main() {
 		configCmd(os.Args[2:])
 	case "run":
 		runCmd(os.Args[2:])
+	case "probe":
+		probeCmd(os.Args[2:])
+	case "agent":
+		agentCmd(os.Args[2:])
+	case "replay":
+		replayCmd(os.Args[2:])
 	case "template":
 		templateCmd(os.Args[2:])
 	default:
@@ -58,29 +71,238 @@ func runCmd(args []string) {
 	nRequests := runOption.Int("n", 0, "Number of requests")
 	tDuration := runOption.Int("t", 0, "Duration of the test in seconds")
 	nParallel := runOption.Int("c", 1, "Number of concurrent requests")
+	nIterations := runOption.Int("i", 0, "Number of requests sent by each concurrent request")
+	nRate := runOption.Float64("rate", 0, "Number of requests per second")
+	stagesSpec := runOption.String("stages", "", "Stages changing the number of concurrent requests")
+	warmup := runOption.Int("warmup", 0, "Initial seconds of the test excluded from stats")
+	abortErrors := runOption.Float64("abort-errors", 0, "Abort when the percentage of failed requests in the window is above")
+	abortLatency := runOption.Int("abort-latency", 0, "Abort when p99 in milliseconds of the window is above")
+	abortWindow := runOption.Int("abort-window", 100, "Number of requests used by \"-abort-errors\" and \"-abort-latency\"")
+	abortConnErrors := runOption.Int("abort-conn-errors", 0, "Abort after a number of consecutive connection errors")
 	configFile := runOption.String("config", "", "Config file to setup HTTP client")
 	dataFile := runOption.String("data", "", "CSV file with data for request generation")
 	outputFile := runOption.String("output", "", "CVS file with detailed execution results")
+	agentList := runOption.String("agents", "", "Comma separated list of agent addresses")
+	isScenario := runOption.Bool("scenario", false, "The template file is a scenario file")
+	adminAddress := runOption.String("admin", "", "TCP address of the admin API used to change the test while running")
 	runOption.Parse(args)
 	nonFlagArgs := runOption.Args()
 
+	if len(nonFlagArgs) == 0 {
+		cmd.Help()
+		return
+	}
+
+	templates := make([]cmd.WeightedFile, 0, len(nonFlagArgs))
+	for _, arg := range nonFlagArgs {
+		file, ok := parseWeightedFile(arg)
+		if !ok {
+			cmd.Help()
+			return
+		}
+
+		templates = append(templates, file)
+	}
+
+	if *nRequests < 0 || *nParallel <= 0 || *nIterations < 0 || *tDuration < 0 || *nRate < 0 || *warmup < 0 {
+		cmd.Help()
+		return
+	}
+
+	if *abortErrors < 0 || *abortLatency < 0 || *abortWindow <= 0 || *abortConnErrors < 0 {
+		cmd.Help()
+		return
+	}
+
+	load := control.Load{
+		Requests:    *nRequests,
+		Duration:    *tDuration,
+		Concurrency: *nParallel,
+		Iterations:  *nIterations,
+		Rate:        *nRate,
+	}
+
+	if *nIterations > 0 {
+		if *nRequests > 0 || *tDuration > 0 || *nRate > 0 || *stagesSpec != "" {
+			cmd.Help()
+			return
+		}
+	} else if *stagesSpec != "" {
+		// The stages define the number of concurrent requests
+		if *nRequests > 0 || *tDuration > 0 || *nRate > 0 || isSet(runOption, "c") {
+			cmd.Help()
+			return
+		}
+
+		stages, err := control.ParseStages(*stagesSpec)
+		if err != nil {
+			log.Fatalf("Invalid stages: %v\n", err)
+		}
+
+		load.Stages = stages
+	} else if (*nRequests == 0 && *tDuration == 0) || (*nRequests > 0 && *tDuration > 0) {
+		cmd.Help()
+		return
+	}
+
+	if load.TestDuration() > 0 && *warmup >= load.TestDuration() {
+		cmd.Help()
+		return
+	}
+
+	var agents []string
+	if *agentList != "" {
+		agents = strings.Split(*agentList, ",")
+
+		// Each agent needs at least one request and one concurrent request,
+		// and the admin API only controls local executions
+		if load.MaxConcurrency() < len(agents) || (load.Requests > 0 && load.Requests < len(agents)) || *adminAddress != "" {
+			cmd.Help()
+			return
+		}
+	}
+
+	options := cmd.RunOptions{
+		Load:   load,
+		Warmup: *warmup,
+		Abort: report.AbortCriteria{
+			ErrorRate:        *abortErrors,
+			Latency:          time.Duration(*abortLatency) * time.Millisecond,
+			Window:           *abortWindow,
+			ConnectionErrors: *abortConnErrors,
+		},
+		Agents:     agents,
+		Admin:      *adminAddress,
+		Scenario:   *isScenario,
+		Templates:  templates,
+		ConfigFile: *configFile,
+		DataFile:   *dataFile,
+		OutputFile: *outputFile,
+	}
+	exitCode := cmd.Run(options)
+	os.Exit(exitCode)
+}
+
+// parseWeightedFile parses "<file>[:<weight>]", the default weight is 1
+func parseWeightedFile(arg string) (cmd.WeightedFile, bool) {
+	file := cmd.WeightedFile{FileName: arg, Weight: 1}
+
+	if i := strings.LastIndex(arg, ":"); i > 0 {
+		if weight, err := strconv.Atoi(arg[i+1:]); err == nil {
+			if weight <= 0 {
+				return file, false
+			}
+
+			file.FileName = arg[:i]
+			file.Weight = weight
+		}
+	}
+
+	return file, true
+}
+
+// isSet returns true if the flag was set in the command line
+func isSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
+
+func probeCmd(args []string) {
+	probeOption := flag.NewFlagSet("probe", flag.ExitOnError)
+	useRate := probeOption.Bool("rate", false, "Increase the request rate instead of the concurrent requests")
+	start := probeOption.Float64("start", 1, "Load used on the first step")
+	step := probeOption.Float64("step", 1, "Load added on each step")
+	max := probeOption.Float64("max", 100, "Maximum load to test")
+	stepDuration := probeOption.Int("d", 30, "Duration of each step in seconds")
+	nParallel := probeOption.Int("c", 100, "Maximum number of requests in flight when using \"-rate\"")
+	percentile := probeOption.Int("p", 99, "Percentile used to check the latency limit")
+	latency := probeOption.Int("latency", 0, "Maximum latency in milliseconds for the percentile")
+	errorRate := probeOption.Float64("errors", 1, "Maximum percentage of non successful requests")
+	configFile := probeOption.String("config", "", "Config file to setup HTTP client")
+	dataFile := probeOption.String("data", "", "CSV file with data for request generation")
+	probeOption.Parse(args)
+	nonFlagArgs := probeOption.Args()
+
 	if len(nonFlagArgs) != 1 {
 		cmd.Help()
 		return
 	}
 
-	if *nRequests < 0 || *nParallel <= 0 || *tDuration < 0 {
+	if *start <= 0 || *step <= 0 || *max < *start || *stepDuration <= 0 || *nParallel <= 0 {
+		cmd.Help()
+		return
+	}
+
+	// The number of concurrent requests can't be fractional
+	if !*useRate && (*start != math.Trunc(*start) || *step != math.Trunc(*step)) {
 		cmd.Help()
 		return
 	}
 
-	if (*nRequests == 0 && *tDuration == 0) || (*nRequests > 0 && *tDuration > 0) {
+	if *percentile <= 0 || *percentile > 100 || *latency < 0 || *errorRate < 0 {
 		cmd.Help()
 		return
 	}
 
+	steps := cmd.ProbeSteps{
+		Start:        *start,
+		Step:         *step,
+		Max:          *max,
+		StepDuration: *stepDuration,
+		Rate:         *useRate,
+		Concurrency:  *nParallel,
+	}
+	limits := report.Limits{
+		Percentile: *percentile,
+		Latency:    time.Duration(*latency) * time.Millisecond,
+		ErrorRate:  *errorRate,
+	}
 	fileName := nonFlagArgs[0]
-	cmd.Run(*nRequests, *tDuration, *nParallel, fileName, *configFile, *dataFile, *outputFile)
+	cmd.Probe(steps, limits, fileName, *configFile, *dataFile)
+}
+
+func agentCmd(args []string) {
+	agentOption := flag.NewFlagSet("agent", flag.ExitOnError)
+	address := agentOption.String("listen", ":7070", "TCP address to listen for coordinators")
+	agentOption.Parse(args)
+
+	if len(agentOption.Args()) != 0 {
+		cmd.Help()
+		return
+	}
+
+	cmd.Agent(*address)
+}
+
+func replayCmd(args []string) {
+	replayOption := flag.NewFlagSet("replay", flag.ExitOnError)
+	speed := replayOption.Float64("speed", 1, "Factor applied to the original pace of the requests")
+	nParallel := replayOption.Int("c", 100, "Maximum number of requests in flight")
+	configFile := replayOption.String("config", "", "Config file to setup HTTP client")
+	outputFile := replayOption.String("output", "", "CVS file with detailed execution results")
+	replayOption.Parse(args)
+	nonFlagArgs := replayOption.Args()
+
+	if len(nonFlagArgs) != 1 || *speed <= 0 || *nParallel <= 0 {
+		cmd.Help()
+		return
+	}
+
+	options := cmd.ReplayOptions{
+		Speed:       *speed,
+		Concurrency: *nParallel,
+		ReplayFile:  nonFlagArgs[0],
+		ConfigFile:  *configFile,
+		OutputFile:  *outputFile,
+	}
+	exitCode := cmd.Replay(options)
+	os.Exit(exitCode)
 }
 
 func templateCmd(args []string) {
@@ -0,0 +1,245 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jjmrocha/beast/config"
+)
+
+// authenticator adds the credentials to the requests
+type authenticator interface {
+	authorization(ctx context.Context) (string, error)
+}
+
+// newAuthenticator creates the authenticator of the configuration, nil without authentication,
+// tokens are requested using the transport of the client
+func newAuthenticator(cfg *config.Config, transport http.RoundTripper) authenticator {
+	auth := cfg.Auth
+
+	switch auth.Type {
+	case config.BasicAuth:
+		req := http.Request{Header: make(http.Header)}
+		req.SetBasicAuth(auth.Username, auth.Password)
+		return staticAuth(req.Header.Get("Authorization"))
+	case config.BearerAuth:
+		return staticAuth("Bearer " + auth.Token)
+	case config.OAuth2Auth:
+		return &oauth2Auth{
+			config: auth,
+			client: &http.Client{
+				Transport: transport,
+				Timeout:   time.Duration(cfg.RequestTimeout) * time.Second,
+			},
+		}
+	}
+
+	return nil
+}
+
+type staticAuth string
+
+func (a staticAuth) authorization(ctx context.Context) (string, error) {
+	return string(a), nil
+}
+
+// Fraction of the lifetime of a token after which it is refreshed
+const refreshAfter = 0.8
+
+// oauth2Auth uses the client credentials grant, the token is refreshed in background
+// before it expires, while the current token is still used
+type oauth2Auth struct {
+	config     config.Auth
+	client     *http.Client
+	mutex      sync.Mutex
+	token      string
+	refreshAt  time.Time
+	expiresAt  time.Time
+	refreshing bool
+	// Request of a token without a valid one, shared by the requests waiting for it
+	fetch *tokenFetch
+	// Error of the last request of a token, returned until failedUntil
+	failure     error
+	failedUntil time.Time
+}
+
+// tokenFetch is a request of a token, done is closed when it completes
+type tokenFetch struct {
+	done      chan struct{}
+	token     string
+	err       error
+	cancelled bool
+}
+
+// Time a failure of the token endpoint is remembered, the requests fail without requesting a token
+const failureBackoff = time.Second
+
+func (a *oauth2Auth) authorization(ctx context.Context) (string, error) {
+	a.mutex.Lock()
+
+	now := time.Now()
+	if a.token != "" && now.Before(a.expiresAt) {
+		if !now.Before(a.refreshAt) && !a.refreshing {
+			a.refreshing = true
+			go a.refresh()
+		}
+
+		token := a.token
+		a.mutex.Unlock()
+		return "Bearer " + token, nil
+	}
+
+	if now.Before(a.failedUntil) {
+		err := a.failure
+		a.mutex.Unlock()
+		return "", err
+	}
+
+	// Without a valid token the requests wait for the one being requested
+	if fetch := a.fetch; fetch != nil {
+		a.mutex.Unlock()
+		return a.wait(ctx, fetch)
+	}
+
+	fetch := &tokenFetch{done: make(chan struct{})}
+	a.fetch = fetch
+	a.mutex.Unlock()
+
+	token, lifetime, err := a.requestToken(ctx)
+
+	a.mutex.Lock()
+	a.fetch = nil
+	fetch.token, fetch.err, fetch.cancelled = token, err, ctx.Err() != nil
+
+	if err == nil {
+		a.update(token, lifetime)
+	} else if !fetch.cancelled {
+		a.failure = err
+		a.failedUntil = time.Now().Add(failureBackoff)
+	}
+
+	a.mutex.Unlock()
+	close(fetch.done)
+
+	if err != nil {
+		return "", err
+	}
+
+	return "Bearer " + token, nil
+}
+
+// wait returns the token of fetch, or requests a new one if fetch was cancelled by the context of other request
+func (a *oauth2Auth) wait(ctx context.Context, fetch *tokenFetch) (string, error) {
+	select {
+	case <-fetch.done:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	if fetch.cancelled && ctx.Err() == nil {
+		return a.authorization(ctx)
+	}
+
+	if fetch.err != nil {
+		return "", fetch.err
+	}
+
+	return "Bearer " + fetch.token, nil
+}
+
+func (a *oauth2Auth) refresh() {
+	token, lifetime, err := a.requestToken(context.Background())
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.refreshing = false
+	if err != nil {
+		// Tries again on the next request, until the token expires
+		return
+	}
+
+	a.update(token, lifetime)
+}
+
+// update must be called with the mutex locked
+func (a *oauth2Auth) update(token string, lifetime time.Duration) {
+	now := time.Now()
+	a.token = token
+	a.expiresAt = now.Add(lifetime)
+	a.refreshAt = now.Add(time.Duration(float64(lifetime) * refreshAfter))
+}
+
+// tokenResponse is the response of the token endpoint (RFC 6749)
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Lifetime used when the token endpoint doesn't return one
+const defaultTokenLifetime = time.Hour
+
+func (a *oauth2Auth) requestToken(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(a.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(a.config.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", a.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("error requesting token: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(a.config.ClientID), url.QueryEscape(a.config.ClientSecret))
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("error requesting token: %v", err)
+	}
+
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("error requesting token: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("error requesting token: status %v", resp.StatusCode)
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		return "", 0, fmt.Errorf("invalid token response: %s", body)
+	}
+
+	lifetime := time.Duration(token.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultTokenLifetime
+	}
+
+	return token.AccessToken, lifetime, nil
+}
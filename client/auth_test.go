@@ -0,0 +1,233 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jjmrocha/beast/config"
+)
+
+func authServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+}
+
+func executeAuth(client *Client, url string, header string) *Response {
+	req, _ := http.NewRequest("GET", url, nil)
+	if header != "" {
+		req.Header.Set("Authorization", header)
+	}
+
+	return client.Execute(context.Background(), &Request{native: req, Capture: true})
+}
+
+func TestStaticAuth(t *testing.T) {
+	// given
+	server := authServer()
+	defer server.Close()
+
+	var tests = []struct {
+		auth     config.Auth
+		header   string
+		expected string
+	}{
+		{config.Auth{Type: config.NoAuth}, "", ""},
+		{config.Auth{Type: config.BasicAuth, Username: "user", Password: "secret"}, "", "Basic dXNlcjpzZWNyZXQ="},
+		{config.Auth{Type: config.BearerAuth, Token: "abc"}, "", "Bearer abc"},
+		{config.Auth{Type: config.BearerAuth, Token: "abc"}, "Bearer template", "Bearer template"},
+	}
+	// then
+	for _, test := range tests {
+		cfg := config.Default()
+		cfg.Auth = test.auth
+		client, _ := NewClient(cfg, 1)
+
+		if result := executeAuth(client, server.URL, test.header); string(result.Body) != test.expected {
+			t.Errorf("got %q expected %q for %v", result.Body, test.expected, test.auth.Type)
+		}
+	}
+}
+
+func TestOAuth2Auth(t *testing.T) {
+	// given
+	server := authServer()
+	defer server.Close()
+
+	var tokens int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "beast" || secret != "secret" || r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "read write" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		fmt.Fprintf(w, `{"access_token": "t%v", "token_type": "Bearer", "expires_in": 1}`, atomic.AddInt32(&tokens, 1))
+	}))
+	defer tokenServer.Close()
+
+	cfg := config.Default()
+	cfg.Auth = config.Auth{
+		Type:         config.OAuth2Auth,
+		TokenURL:     tokenServer.URL,
+		ClientID:     "beast",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	}
+	client, _ := NewClient(cfg, 1)
+	// when
+	first := executeAuth(client, server.URL, "")
+	cached := executeAuth(client, server.URL, "")
+	time.Sleep(900 * time.Millisecond)
+	beforeRefresh := executeAuth(client, server.URL, "")
+	time.Sleep(50 * time.Millisecond)
+	refreshed := executeAuth(client, server.URL, "")
+	// then
+	var results = []struct {
+		response *Response
+		expected string
+	}{
+		{first, "Bearer t1"},
+		{cached, "Bearer t1"},
+		{beforeRefresh, "Bearer t1"},
+		{refreshed, "Bearer t2"},
+	}
+	for i, result := range results {
+		if string(result.response.Body) != result.expected {
+			t.Errorf("got %q expected %q for request %v", result.response.Body, result.expected, i)
+		}
+	}
+}
+
+func TestOAuth2AuthFailure(t *testing.T) {
+	// given
+	server := authServer()
+	defer server.Close()
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer tokenServer.Close()
+
+	cfg := config.Default()
+	cfg.Auth = config.Auth{Type: config.OAuth2Auth, TokenURL: tokenServer.URL, ClientID: "beast"}
+	client, _ := NewClient(cfg, 1)
+	// when
+	result := executeAuth(client, server.URL, "")
+	// then
+	if result.StatusCode != AuthFailure || result.Error == "" {
+		t.Errorf("got %v (%v) expected %v", result.StatusCode, result.Error, AuthFailure)
+	}
+}
+
+func TestOAuth2AuthSharedFetch(t *testing.T) {
+	// given
+	server := authServer()
+	defer server.Close()
+
+	var tokens int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&tokens, 1)
+		time.Sleep(100 * time.Millisecond)
+		fmt.Fprint(w, `{"access_token": "t1", "expires_in": 3600}`)
+	}))
+	defer tokenServer.Close()
+
+	cfg := config.Default()
+	cfg.Auth = config.Auth{Type: config.OAuth2Auth, TokenURL: tokenServer.URL, ClientID: "beast"}
+	client, _ := NewClient(cfg, 5)
+	// when
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if result := executeAuth(client, server.URL, ""); string(result.Body) != "Bearer t1" {
+				t.Errorf("got %q expected %q", result.Body, "Bearer t1")
+			}
+		}()
+	}
+
+	wg.Wait()
+	// then
+	if tokens != 1 {
+		t.Errorf("got %v token requests expected 1", tokens)
+	}
+}
+
+func TestOAuth2AuthFailureBackoff(t *testing.T) {
+	// given
+	server := authServer()
+	defer server.Close()
+
+	var tokens int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&tokens, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer tokenServer.Close()
+
+	cfg := config.Default()
+	cfg.Auth = config.Auth{Type: config.OAuth2Auth, TokenURL: tokenServer.URL, ClientID: "beast"}
+	client, _ := NewClient(cfg, 1)
+	// when
+	first := executeAuth(client, server.URL, "")
+	second := executeAuth(client, server.URL, "")
+	// then
+	if first.StatusCode != AuthFailure || second.StatusCode != AuthFailure {
+		t.Errorf("got %v and %v expected %v", first.StatusCode, second.StatusCode, AuthFailure)
+	}
+
+	if tokens != 1 {
+		t.Errorf("got %v token requests expected 1", tokens)
+	}
+}
+
+func TestOAuth2AuthCancelled(t *testing.T) {
+	// given
+	server := authServer()
+	defer server.Close()
+
+	release := make(chan bool)
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer tokenServer.Close()
+	defer close(release)
+
+	cfg := config.Default()
+	cfg.Auth = config.Auth{Type: config.OAuth2Auth, TokenURL: tokenServer.URL, ClientID: "beast"}
+	client, _ := NewClient(cfg, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	// when
+	start := time.Now()
+	result := client.Execute(ctx, &Request{native: req})
+	// then
+	if result.StatusCode != Cancelled || time.Since(start) > time.Second {
+		t.Errorf("got %v after %v expected %v", result.StatusCode, time.Since(start), Cancelled)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+// Status codes of the requests without a response from the endpoint
+const (
+	GenerationError   = -100
+	Cancelled         = -300
+	HeaderTimeout     = -400
+	ConnectTimeout    = -401
+	BodyTimeout       = -402
+	UnexpectedError   = -500
+	DNSFailure        = -501
+	ConnectionRefused = -502
+	ConnectionReset   = -503
+	TLSFailure        = -504
+	TooManyRedirects  = -505
+	// The credentials of the configuration couldn't be obtained, e.g. the OAuth2 token endpoint failed,
+	// the request wasn't sent, so it isn't an error of the endpoint
+	AuthFailure = -600
+)
+
+var clientErrors = map[int]string{
+	GenerationError:   "Request generation error",
+	Cancelled:         "Cancelled at end of test",
+	HeaderTimeout:     "Response header timeout",
+	ConnectTimeout:    "Connect timeout",
+	BodyTimeout:       "Body read timeout",
+	UnexpectedError:   "Unexpected error",
+	DNSFailure:        "DNS failure",
+	ConnectionRefused: "Connection refused",
+	ConnectionReset:   "Connection reset",
+	TLSFailure:        "TLS handshake failure",
+	TooManyRedirects:  "Too many redirects",
+	AuthFailure:       "Authentication failure",
+}
+
+// maxRedirects is the limit used by the net/http default redirect policy
+const maxRedirects = 10
+
+var errTooManyRedirects = errors.New("stopped after 10 redirects")
+
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errTooManyRedirects
+	}
+
+	return nil
+}
+
+// classify returns the status code of the error, readingBody is true if the error was returned reading the body
+func (p *progress) classify(err error, readingBody bool) int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	connected := !p.gotConn.IsZero()
+	var timeout interface{ Timeout() bool }
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+
+	switch {
+	case errors.Is(err, errTooManyRedirects):
+		return TooManyRedirects
+	case errors.As(err, &timeout) && timeout.Timeout():
+		if readingBody {
+			return BodyTimeout
+		}
+
+		if !connected {
+			return ConnectTimeout
+		}
+
+		return HeaderTimeout
+	case errors.As(err, &dnsErr):
+		return DNSFailure
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET):
+		return ConnectionReset
+	case p.tlsFailed:
+		return TLSFailure
+	case errors.As(err, &opErr) && opErr.Op == "remote error":
+		// TLS alerts sent by the server, e.g. rejecting the client certificate after a TLS 1.3 handshake
+		return TLSFailure
+	}
+
+	return UnexpectedError
+}
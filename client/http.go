@@ -18,13 +18,13 @@
 package client
 
 import (
-	"crypto/tls"
-	"errors"
+	"context"
 	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"net/url"
+	"net/http/cookiejar"
+	"net/http/httptrace"
 	"time"
 
 	"github.com/jjmrocha/beast/config"
@@ -38,22 +38,23 @@ type httpClient interface {
 // Client represents an HTTP client
 type Client struct {
 	native httpClient
+	auth   authenticator
+	signer signer
 }
 
-// NewClient creates a client.Client based on the provided configuration
-func NewClient(cfg *config.Config, parallelConns int) *Client {
-	tls := &tls.Config{
-		InsecureSkipVerify: cfg.DisableCertificateCheck,
+// NewClient creates a client.Client based on the provided configuration,
+// returning an error if the files or the signing keys of the configuration can't be read
+func NewClient(cfg *config.Config, parallelConns int) (*Client, error) {
+	transport, err := newTransport(cfg, parallelConns)
+	if err != nil {
+		return nil, err
 	}
-	maxIdleConns := cfg.GetMaxIdleConnections(parallelConns)
-	transport := &http.Transport{
-		DisableCompression:  cfg.DisableCompression,
-		DisableKeepAlives:   cfg.DisableKeepAlives,
-		MaxConnsPerHost:     cfg.MaxConnections,
-		MaxIdleConns:        maxIdleConns,
-		MaxIdleConnsPerHost: maxIdleConns,
-		TLSClientConfig:     tls,
+
+	signer, err := newSigner(cfg)
+	if err != nil {
+		return nil, err
 	}
+
 	native := &http.Client{
 		Transport: transport,
 		Timeout:   time.Duration(time.Second.Nanoseconds() * int64(cfg.RequestTimeout)),
@@ -63,46 +64,128 @@ func NewClient(cfg *config.Config, parallelConns int) *Client {
 		native.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		}
+	} else {
+		native.CheckRedirect = checkRedirect
 	}
 
 	return &Client{
 		native: native,
+		auth:   newAuthenticator(cfg, transport),
+		signer: signer,
+	}, nil
+}
+
+// WithCookieJar returns a client sharing the connections of c, with its own cookie jar,
+// used to keep the cookies of a virtual user
+func (c *Client) WithCookieJar() *Client {
+	native, ok := c.native.(*http.Client)
+	if !ok {
+		return c
+	}
+
+	jar, _ := cookiejar.New(nil)
+	withJar := *native
+	withJar.Jar = jar
+
+	return &Client{
+		native: &withJar,
+		auth:   c.auth,
+		signer: c.signer,
 	}
 }
 
-// Execute executes the request measuring the time taken to execute and return a client.Response
-func (c *Client) Execute(request *Request) *Response {
-	start := time.Now()
-	resp, err := c.native.Do(request.native)
+// Execute executes the request measuring the time taken to execute and return a client.Response,
+// for scheduled requests the time is measured from the scheduled time, including any queueing delay,
+// the request is cancelled when ctx is done
+func (c *Client) Execute(ctx context.Context, request *Request) *Response {
+	if err := c.authorize(ctx, request); err != nil {
+		// The request wasn't sent
+		response := &Response{
+			Timestamp: request.start(),
+			Request:   request.String(),
+		}
+		c.failed(ctx, response, err, AuthFailure)
+		return response
+	}
+
+	var progress progress
+	start := request.start()
+	native := request.withContext(httptrace.WithClientTrace(ctx, progress.trace()))
+	body := countBody(native)
+	resp, err := c.native.Do(native)
 	duration := time.Since(start)
 
+	response := &Response{
+		Timestamp:   start,
+		Request:     request.String(),
+		Duration:    duration,
+		RequestSize: request.size(body),
+	}
+
 	if err != nil {
-		var urlErr *url.Error
-		if ok := errors.As(err, &urlErr); ok && urlErr.Timeout() {
-			return &Response{
-				Timestamp:  start,
-				Request:    request.String(),
-				StatusCode: -400,
-				Duration:   duration,
-			}
-		}
+		response.Phases = progress.phases(time.Time{})
+		c.failed(ctx, response, err, progress.classify(err, false))
+		return response
+	}
+
+	defer resp.Body.Close()
+	response.StatusCode = resp.StatusCode
+	response.Protocol = resp.Proto
 
-		log.Printf("Error executing request '%v': %v\n", request, err)
-		return &Response{
-			Timestamp:  start,
-			Request:    request.String(),
-			StatusCode: -500,
-			Duration:   duration,
+	var bodySize int64
+	if request.Capture {
+		response.Body, err = ioutil.ReadAll(resp.Body)
+		bodySize = int64(len(response.Body))
+		response.Header = resp.Header
+	} else {
+		bodySize, err = io.Copy(ioutil.Discard, resp.Body)
+	}
+
+	response.ResponseSize = responseHeadSize(resp) + bodySize
+
+	response.Phases = progress.phases(time.Now())
+
+	if err != nil {
+		c.failed(ctx, response, err, progress.classify(err, true))
+	}
+
+	return response
+}
+
+// authorize adds the credentials of the configuration, unless the request has its own,
+// and then the signature of the configuration
+func (c *Client) authorize(ctx context.Context, request *Request) error {
+	if request.native == nil {
+		return nil
+	}
+
+	if c.auth != nil && request.native.Header.Get("Authorization") == "" {
+		authorization, err := c.auth.authorization(ctx)
+		if err != nil {
+			return err
 		}
+
+		request.native.Header.Set("Authorization", authorization)
 	}
 
-	defer resp.Body.Close()
-	io.Copy(ioutil.Discard, resp.Body)
+	if c.signer != nil {
+		return c.signer.sign(request.native)
+	}
 
-	return &Response{
-		Timestamp:  start,
-		Request:    request.String(),
-		StatusCode: resp.StatusCode,
-		Duration:   duration,
+	return nil
+}
+
+// failed sets the client error of the response, requests cancelled by ctx are reported as Cancelled
+func (c *Client) failed(ctx context.Context, response *Response, err error, statusCode int) {
+	if ctx.Err() != nil {
+		statusCode = Cancelled
+	}
+
+	if statusCode == UnexpectedError {
+		log.Printf("Error executing request '%v': %v\n", response.Request, err)
 	}
+
+	response.StatusCode = statusCode
+	response.Error = err.Error()
+	response.Body, response.Header = nil, nil
 }
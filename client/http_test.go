@@ -17,9 +17,15 @@
 package client
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -39,7 +45,7 @@ func TestHTTPTimeout(t *testing.T) {
 	for _, test := range tests {
 		config := config.Default()
 		config.RequestTimeout = test.input
-		result := NewClient(config, 10)
+		result, _ := NewClient(config, 10)
 		if client, ok := result.native.(*http.Client); ok && client.Timeout != test.expected {
 			t.Errorf("got %v expected %v for RequestTimeout", client.Timeout, test.expected)
 		}
@@ -68,9 +74,9 @@ func (t timeoutMockedClient) Do(r *http.Request) (*http.Response, error) {
 func TestExecuteTimeout(t *testing.T) {
 	// given
 	client := &Client{native: timeoutMockedClient(true)}
-	expected := -400
+	expected := ConnectTimeout
 	// when
-	result := client.Execute(&Request{})
+	result := client.Execute(context.Background(), &Request{})
 	// then
 	if result.StatusCode != expected {
 		t.Errorf("got %v expected %v", result.StatusCode, expected)
@@ -80,11 +86,198 @@ func TestExecuteTimeout(t *testing.T) {
 func TestExecuteGeneric(t *testing.T) {
 	// given
 	client := &Client{native: timeoutMockedClient(false)}
-	expected := -500
+	expected := UnexpectedError
 	// when
-	result := client.Execute(&Request{})
+	result := client.Execute(context.Background(), &Request{})
 	// then
 	if result.StatusCode != expected {
 		t.Errorf("got %v expected %v", result.StatusCode, expected)
 	}
 }
+
+func TestExecuteCancelled(t *testing.T) {
+	// given
+	client := &Client{native: timeoutMockedClient(true)}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	expected := Cancelled
+	// when
+	result := client.Execute(ctx, &Request{})
+	// then
+	if result.StatusCode != expected {
+		t.Errorf("got %v expected %v", result.StatusCode, expected)
+	}
+}
+
+func TestExecuteClientErrors(t *testing.T) {
+	// given
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow-header":
+			time.Sleep(1500 * time.Millisecond)
+		case "/slow-body":
+			w.Write([]byte("partial"))
+			w.(http.Flusher).Flush()
+			time.Sleep(1500 * time.Millisecond)
+		case "/redirect":
+			http.Redirect(w, r, "/redirect", http.StatusFound)
+		case "/reset":
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.(*net.TCPConn).SetLinger(0)
+			conn.Close()
+		}
+	}))
+	defer server.Close()
+
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	closedURL := "http://" + listener.Addr().String()
+	listener.Close()
+
+	cfg := config.Default()
+	cfg.RequestTimeout = 1
+	cfg.DisableRedirects = false
+	client, _ := NewClient(cfg, 1)
+
+	var tests = []struct {
+		url      string
+		expected int
+	}{
+		{server.URL + "/slow-header", HeaderTimeout},
+		{server.URL + "/slow-body", BodyTimeout},
+		{server.URL + "/redirect", TooManyRedirects},
+		{server.URL + "/reset", ConnectionReset},
+		{tlsServer.URL, TLSFailure},
+		{closedURL, ConnectionRefused},
+		{"http://beast.invalid", DNSFailure},
+	}
+	// then
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.url, nil)
+		result := client.Execute(context.Background(), &Request{native: req})
+		if result.StatusCode != test.expected {
+			t.Errorf("got %v (%v) expected %v for %v", result.StatusCode, result.Error, test.expected, test.url)
+		}
+
+		if result.Error == "" {
+			t.Errorf("expected error message for %v", test.url)
+		}
+	}
+}
+
+func TestExecutePhases(t *testing.T) {
+	// given
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	cfg := config.Default()
+	cfg.DisableCertificateCheck = true
+	client, _ := NewClient(cfg, 1)
+	request := func() *Response {
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		return client.Execute(context.Background(), &Request{native: req})
+	}
+	// when
+	first := request()
+	second := request()
+	// then
+	if first.Phases.Connect == 0 || first.Phases.TLS == 0 || first.Phases.FirstByte < 10*time.Millisecond {
+		t.Errorf("got %+v for a new connection", first.Phases)
+	}
+
+	if second.Phases.Connect != 0 || second.Phases.TLS != 0 || second.Phases.FirstByte < 10*time.Millisecond {
+		t.Errorf("got %+v for a reused connection", second.Phases)
+	}
+}
+
+func TestExecuteSizes(t *testing.T) {
+	// given
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, 1000))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(config.Default(), 1)
+	head := int64(len("POST / HTTP/1.1\r\nHost: " + server.Listener.Addr().String() + "\r\n\r\n"))
+	// then
+	for _, capture := range []bool{false, true} {
+		req, _ := http.NewRequest("POST", server.URL+"/", strings.NewReader("hello"))
+		result := client.Execute(context.Background(), &Request{native: req, Capture: capture})
+		if result.RequestSize != head+5 {
+			t.Errorf("got %v bytes expected %v with capture %v", result.RequestSize, head+5, capture)
+		}
+
+		// Status line, headers (Date, Content-Length and Content-Type) and body
+		if result.ResponseSize < 1100 || result.ResponseSize > 1200 {
+			t.Errorf("got %v bytes expected about 1150 with capture %v", result.ResponseSize, capture)
+		}
+	}
+}
+
+func TestExecuteChunkedSizes(t *testing.T) {
+	// given
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		for i := 0; i < 10; i++ {
+			w.Write(make([]byte, 100))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(config.Default(), 1)
+	// A reader of unknown length is sent chunked
+	req, _ := http.NewRequest("POST", server.URL, io.MultiReader(strings.NewReader(strings.Repeat("x", 1000))))
+	// when
+	result := client.Execute(context.Background(), &Request{native: req})
+	// then
+	if req.ContentLength != 0 || result.RequestSize < 1000 {
+		t.Errorf("got %v bytes expected more than 1000 for a chunked request", result.RequestSize)
+	}
+
+	if result.ResponseSize < 1000 {
+		t.Errorf("got %v bytes expected more than 1000 for a chunked response", result.ResponseSize)
+	}
+}
+
+func TestWithCookieJar(t *testing.T) {
+	// given
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("session"); err == nil {
+			w.Write([]byte(cookie.Value))
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: r.URL.Query().Get("user")})
+	}))
+	defer server.Close()
+
+	base, _ := NewClient(config.Default(), 2)
+	first := base.WithCookieJar()
+	second := base.WithCookieJar()
+	request := func(client *Client, user string) string {
+		req, _ := http.NewRequest("GET", server.URL+"?user="+user, nil)
+		response := client.Execute(context.Background(), &Request{native: req, Capture: true})
+		return string(response.Body)
+	}
+	// when
+	request(first, "u1")
+	request(second, "u2")
+	// then
+	if result := request(first, "u1"); result != "u1" {
+		t.Errorf("got %v expected u1 for first client", result)
+	}
+
+	if result := request(second, "u2"); result != "u2" {
+		t.Errorf("got %v expected u2 for second client", result)
+	}
+
+	if result := request(base, "u3"); result != "" {
+		t.Errorf("got %v expected no cookies for base client", result)
+	}
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jjmrocha/beast/config"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// proxyFunc returns the function that chooses the proxy of each request,
+// the configuration was already checked
+func proxyFunc(cfg config.Proxy) func(*http.Request) (*url.URL, error) {
+	proxyConfig := httpproxy.FromEnvironment()
+
+	if cfg.URL != "" {
+		proxyURL, _ := url.Parse(cfg.URL)
+		if cfg.Username != "" {
+			proxyURL.User = url.UserPassword(cfg.Username, cfg.Password)
+		}
+
+		proxyConfig = &httpproxy.Config{
+			HTTPProxy:  proxyURL.String(),
+			HTTPSProxy: proxyURL.String(),
+		}
+	}
+
+	if len(cfg.Bypass) > 0 {
+		bypass := strings.Join(cfg.Bypass, ",")
+		if proxyConfig.NoProxy != "" {
+			bypass = proxyConfig.NoProxy + "," + bypass
+		}
+
+		proxyConfig.NoProxy = bypass
+	}
+
+	proxy := proxyConfig.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}
+}
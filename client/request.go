@@ -17,13 +17,20 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // Request represents an HTTP request
 type Request struct {
 	native *http.Request
+	// Scheduled is the time when the request should have been sent,
+	// when set the response duration is measured from this time
+	Scheduled time.Time
+	// When true the response body and headers are kept in the Response
+	Capture bool
 }
 
 // BuildRequest creates a client.Request using a http.Request
@@ -38,3 +45,38 @@ func (r *Request) String() string {
 
 	return fmt.Sprintf("%s %s", r.native.Method, r.native.URL)
 }
+
+func (r *Request) start() time.Time {
+	if r.Scheduled.IsZero() {
+		return time.Now()
+	}
+
+	return r.Scheduled
+}
+
+// size returns the number of bytes of the request, headers included,
+// body counts the bytes sent of a body of unknown length, nil for the others
+func (r *Request) size(body *sentBody) int64 {
+	if r.native == nil {
+		return 0
+	}
+
+	size := requestHeadSize(r.native)
+	if body != nil {
+		return size + body.size()
+	}
+
+	if r.native.ContentLength > 0 {
+		size += r.native.ContentLength
+	}
+
+	return size
+}
+
+func (r *Request) withContext(ctx context.Context) *http.Request {
+	if r.native == nil {
+		return nil
+	}
+
+	return r.native.WithContext(ctx)
+}
@@ -18,6 +18,7 @@ package client
 
 import (
 	"fmt"
+	"net/http"
 	"time"
 )
 
@@ -27,15 +28,38 @@ type Response struct {
 	Request    string
 	StatusCode int
 	Duration   time.Duration
+	// Index of the load stage active when the request was sent
+	Stage int
+	// Bytes of the request sent and of the response received, headers included (in the HTTP/1.1 format),
+	// the bodies of unknown length (chunked) are counted while sent or received
+	RequestSize  int64
+	ResponseSize int64
+	// Time spent on each phase of the request
+	Phases Phases
+	// Description of the template check the response failed, empty if it passed all or there are no checks
+	FailedCheck string
+	// True when the status code is one of the status codes expected by the template checks
+	ExpectedStatus bool
+	// Protocol used by the response, e.g. "HTTP/1.1" or "HTTP/2.0"
+	Protocol string
+	// Name of the template chosen from a mix of templates, empty when there is only one
+	Template string
+	// True when the request was sent during the warm-up period
+	Warmup bool
+	// Message of the error of the requests without a response (negative status code)
+	Error string
+	// Body and Header are only kept when Request.Capture is true
+	Body   []byte
+	Header http.Header
 }
 
 func (r *Response) String() string {
 	return fmt.Sprintf("%v - %v", r.StatusCode, r.Duration)
 }
 
-// IsSuccess return true for statusCodes matchs 2xx
+// IsSuccess return true for statusCodes matchs 2xx or expected by the template checks, that passed the template checks
 func (r *Response) IsSuccess() bool {
-	return r.StatusCode >= 200 && r.StatusCode < 300
+	return (r.StatusCode >= 200 && r.StatusCode < 300 || r.ExpectedStatus) && r.FailedCheck == ""
 }
 
 // IsClientError returns true if we didn't receive an awnser from the endpoint
@@ -43,16 +67,17 @@ func (r *Response) IsClientError() bool {
 	return r.StatusCode < 0
 }
 
-// ClientError returns the descriprion for the client error
-func (r *Response) ClientError() string {
+// IsConnectionError returns true if the client couldn't connect to the endpoint
+func (r *Response) IsConnectionError() bool {
 	switch r.StatusCode {
-	case -100:
-		return "Request generation error"
-	case -400:
-		return "Request timeout"
-	case -500:
-		return "Unexpected error"
+	case DNSFailure, ConnectionRefused, ConnectionReset, TLSFailure, ConnectTimeout:
+		return true
 	}
 
-	return ""
+	return false
+}
+
+// ClientError returns the descriprion for the client error
+func (r *Response) ClientError() string {
+	return clientErrors[r.StatusCode]
 }
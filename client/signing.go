@@ -0,0 +1,344 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jjmrocha/beast/config"
+)
+
+// signer adds the signature to the requests, after the credentials of the authenticator
+type signer interface {
+	sign(req *http.Request) error
+}
+
+// newSigner creates the signer of the configuration, nil without signing,
+// returning an error if the environment variables with the keys are not set
+func newSigner(cfg *config.Config) (signer, error) {
+	signing := cfg.Signing
+
+	switch signing.Type {
+	case config.HMACSigning:
+		key, err := getenv(signing.KeyEnv)
+		if err != nil {
+			return nil, err
+		}
+
+		signer := &hmacSigner{
+			key:             []byte(key),
+			hash:            sha256.New,
+			header:          signing.Header,
+			timestampHeader: signing.TimestampHeader,
+			now:             time.Now,
+		}
+
+		if signing.Algorithm == config.SHA512 {
+			signer.hash = sha512.New
+		}
+
+		if signer.header == "" {
+			signer.header = "X-Signature"
+		}
+
+		if signer.timestampHeader == "" {
+			signer.timestampHeader = "X-Timestamp"
+		}
+
+		return signer, nil
+	case config.AWSSigning:
+		accessKey, err := getenv("AWS_ACCESS_KEY_ID")
+		if err != nil {
+			return nil, err
+		}
+
+		secretKey, err := getenv("AWS_SECRET_ACCESS_KEY")
+		if err != nil {
+			return nil, err
+		}
+
+		return &awsSigner{
+			accessKey:    accessKey,
+			secretKey:    secretKey,
+			sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+			region:       signing.Region,
+			service:      signing.Service,
+			now:          time.Now,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+func getenv(name string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", fmt.Errorf("environment variable %s with the signing key is not set", name)
+	}
+
+	return value, nil
+}
+
+// hmacSigner signs the string "<method>\n<path and query>\n<timestamp>\n<hex hash of the body>"
+type hmacSigner struct {
+	key             []byte
+	hash            func() hash.Hash
+	header          string
+	timestampHeader string
+	now             func() time.Time
+}
+
+func (s *hmacSigner) sign(req *http.Request) error {
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	bodyHash := s.hash()
+	bodyHash.Write(body)
+
+	content := strings.Join([]string{
+		req.Method,
+		req.URL.RequestURI(),
+		timestamp,
+		hex.EncodeToString(bodyHash.Sum(nil)),
+	}, "\n")
+
+	mac := hmac.New(s.hash, s.key)
+	mac.Write([]byte(content))
+
+	req.Header.Set(s.timestampHeader, timestamp)
+	req.Header.Set(s.header, hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
+
+// awsSigner implements AWS Signature Version 4 using the "Authorization" header
+type awsSigner struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	region       string
+	service      string
+	now          func() time.Time
+}
+
+func (s *awsSigner) sign(req *http.Request) error {
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	// S3 requires the hash of the body in a header
+	if s.service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	signedHeaders, canonicalHeaders := s.canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s.canonicalPath(req.URL),
+		canonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, s.region, s.service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		req.Header.Get("X-Amz-Date"),
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	for _, part := range []string{s.region, s.service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+	return nil
+}
+
+// canonicalPath encodes each segment of the path as sent, so the segments of every service except S3
+// are encoded twice, S3 segments are decoded before being encoded once
+func (s *awsSigner) canonicalPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if s.service == "s3" {
+			if decoded, err := url.PathUnescape(segment); err == nil {
+				segment = decoded
+			}
+		}
+
+		segments[i] = uriEncode(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// canonicalHeaders returns the names of the signed headers and their canonical form,
+// the host, content type and x-amz-* headers are signed
+func (s *awsSigner) canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			trimmed := make([]string, 0, len(values))
+			for _, value := range values {
+				trimmed = append(trimmed, strings.Join(strings.Fields(value), " "))
+			}
+
+			headers[name] = strings.Join(trimmed, ",")
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	return strings.Join(names, ";"), canonical.String()
+}
+
+// canonicalQuery sorts the parameters by encoded name and then by encoded value
+func canonicalQuery(u *url.URL) string {
+	type param struct {
+		name  string
+		value string
+	}
+
+	query := u.Query()
+	params := make([]param, 0, len(query))
+
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, param{name: uriEncode(name), value: uriEncode(value)})
+		}
+	}
+
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].name != params[j].name {
+			return params[i].name < params[j].name
+		}
+
+		return params[i].value < params[j].value
+	})
+
+	encoded := make([]string, 0, len(params))
+	for _, p := range params {
+		encoded = append(encoded, p.name+"="+p.value)
+	}
+
+	return strings.Join(encoded, "&")
+}
+
+// uriEncode encodes every byte except the unreserved characters of RFC 3986
+func uriEncode(value string) string {
+	var encoded strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			encoded.WriteByte(c)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+
+	return encoded.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// readBody returns the body of the request without consuming it
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+
+		return ioutil.ReadAll(body)
+	}
+
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jjmrocha/beast/config"
+)
+
+func TestAWSSigner(t *testing.T) {
+	// given
+	signer := &awsSigner{
+		accessKey: "AKIDEXAMPLE",
+		secretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		region:    "us-east-1",
+		service:   "service",
+		now: func() time.Time {
+			return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+		},
+	}
+	// AWS Signature Version 4 test suite
+	var tests = []struct {
+		url      string
+		expected string
+	}{
+		{"https://example.amazonaws.com/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"https://example.amazonaws.com/?Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+		{"https://example.amazonaws.com/?Param1=value2&Param1=Value1", "eedbc4e291e521cf13422ffca22be7d2eb8146eecf653089df300a15b2382bd1"},
+		{"https://example.amazonaws.com/?Param1=value2&Param1=value1", "5772eed61e12b33fae39ee5e7012498b51d56abc0abb7c60486157bd471c4694"},
+		{"https://example.amazonaws.com/?-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz=-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+			"9c3e54bfcdf0b19771a7f523ee5669cdf59bc7cc0884027167c21bb143a40197"},
+		{"https://example.amazonaws.com/?%E1%88%B4=bar", "2cdec8eed098649ff3a119c94853b13c643bcf08f8b0a1d91e12c9027818dd04"},
+	}
+	// then
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.url, nil)
+		signer.sign(req)
+		expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=" + test.expected
+		if result := req.Header.Get("Authorization"); result != expected {
+			t.Errorf("got %v expected %v for %v", result, expected, test.url)
+		}
+	}
+}
+
+func TestAWSCanonicalPath(t *testing.T) {
+	// given
+	var tests = []struct {
+		service  string
+		url      string
+		expected string
+	}{
+		{"execute-api", "https://api.example.com", "/"},
+		{"lambda", "https://lambda.amazonaws.com/2015-03-31/functions/arn:aws:lambda:us-east-1:123:function:f/invocations",
+			"/2015-03-31/functions/arn%3Aaws%3Alambda%3Aus-east-1%3A123%3Afunction%3Af/invocations"},
+		{"execute-api", "https://api.example.com/a%20b", "/a%2520b"},
+		{"s3", "https://bucket.s3.amazonaws.com/photos/2023:01:01 summer.jpg", "/photos/2023%3A01%3A01%20summer.jpg"},
+		{"s3", "https://bucket.s3.amazonaws.com/a%20b", "/a%20b"},
+	}
+	// then
+	for _, test := range tests {
+		signer := &awsSigner{service: test.service}
+		u, _ := url.Parse(test.url)
+		if result := signer.canonicalPath(u); result != test.expected {
+			t.Errorf("got %v expected %v for %v", result, test.expected, test.url)
+		}
+	}
+}
+
+func TestAWSCanonicalQuery(t *testing.T) {
+	// given
+	var tests = []struct {
+		query    string
+		expected string
+	}{
+		{"", ""},
+		{"a-b=2&a=1", "a=1&a-b=2"},
+		{"b=2&a=y&a=x", "a=x&a=y&b=2"},
+		{"q=a b&path=/x", "path=%2Fx&q=a%20b"},
+	}
+	// then
+	for _, test := range tests {
+		if result := canonicalQuery(&url.URL{RawQuery: test.query}); result != test.expected {
+			t.Errorf("got %v expected %v for %v", result, test.expected, test.query)
+		}
+	}
+}
+
+func TestHMACSigning(t *testing.T) {
+	// given
+	var received http.Header
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		received, body = r.Header, string(data)
+	}))
+	defer server.Close()
+
+	t.Setenv("BEAST_SIGNING_KEY", "secret")
+	cfg := config.Default()
+	cfg.Signing = config.Signing{Type: config.HMACSigning, KeyEnv: "BEAST_SIGNING_KEY", Header: "X-Sig"}
+	client, _ := NewClient(cfg, 1)
+	req, _ := http.NewRequest("POST", server.URL+"/orders?id=1", strings.NewReader("hello"))
+	// when
+	result := client.Execute(context.Background(), &Request{native: req})
+	// then
+	bodyHash := sha256.Sum256([]byte("hello"))
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("POST\n/orders?id=1\n" + received.Get("X-Timestamp") + "\n" + hex.EncodeToString(bodyHash[:])))
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if result.StatusCode != http.StatusOK || body != "hello" {
+		t.Errorf("got %v with body %q expected 200 with body hello", result.StatusCode, body)
+	}
+
+	if signature := received.Get("X-Sig"); signature != expected {
+		t.Errorf("got signature %v expected %v", signature, expected)
+	}
+}
+
+func TestSigningKeyNotSet(t *testing.T) {
+	// given
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	cfg := config.Default()
+	cfg.Signing = config.Signing{Type: config.AWSSigning, Region: "eu-west-1", Service: "execute-api"}
+	// when
+	_, err := NewClient(cfg, 1)
+	// then
+	if err == nil {
+		t.Error("expected error without AWS_ACCESS_KEY_ID")
+	}
+}
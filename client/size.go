@@ -0,0 +1,84 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// byteCounter is a writer that only counts the bytes written
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
+
+// requestHeadSize returns the bytes of the request line and headers in the HTTP/1.1 format,
+// without the headers added by the transport (e.g. User-Agent), HTTP/2 sends them compressed
+func requestHeadSize(req *http.Request) int64 {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	var counter byteCounter
+	fmt.Fprintf(&counter, "%s %s HTTP/1.1\r\nHost: %s\r\n", req.Method, req.URL.RequestURI(), host)
+	req.Header.Write(&counter)
+	counter.Write([]byte("\r\n"))
+	return int64(counter)
+}
+
+// responseHeadSize returns the bytes of the status line and headers in the HTTP/1.1 format
+func responseHeadSize(resp *http.Response) int64 {
+	var counter byteCounter
+	fmt.Fprintf(&counter, "%s %s\r\n", resp.Proto, resp.Status)
+	resp.Header.Write(&counter)
+	counter.Write([]byte("\r\n"))
+	return int64(counter)
+}
+
+// sentBody counts the bytes of a request body of unknown length (sent chunked) read by the transport,
+// that may still be reading it when the response arrives
+type sentBody struct {
+	io.ReadCloser
+	count int64
+}
+
+// countBody replaces the body of req, when its length is unknown, by a sentBody, otherwise returns nil
+func countBody(req *http.Request) *sentBody {
+	if req == nil || req.Body == nil || req.Body == http.NoBody || req.ContentLength > 0 {
+		return nil
+	}
+
+	body := &sentBody{ReadCloser: req.Body}
+	req.Body = body
+	return body
+}
+
+func (b *sentBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(&b.count, int64(n))
+	return n, err
+}
+
+func (b *sentBody) size() int64 {
+	return atomic.LoadInt64(&b.count)
+}
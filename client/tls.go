@@ -0,0 +1,70 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/jjmrocha/beast/config"
+)
+
+// newTLSConfig creates the TLS configuration, loading the certificate files
+func newTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.DisableCertificateCheck,
+		ServerName:         cfg.TLS.ServerName,
+	}
+
+	var err error
+	if tlsConfig.MinVersion, err = config.TLSVersion(cfg.TLS.MinVersion); err != nil {
+		return nil, err
+	}
+
+	if tlsConfig.MaxVersion, err = config.TLSVersion(cfg.TLS.MaxVersion); err != nil {
+		return nil, err
+	}
+
+	if tlsConfig.CipherSuites, err = config.CipherSuites(cfg.TLS.CipherSuites); err != nil {
+		return nil, err
+	}
+
+	if cfg.TLS.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading client certificate %s: %v", cfg.TLS.CertFile, err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.TLS.CAFile != "" {
+		pem, err := ioutil.ReadFile(cfg.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA file %s: %v", cfg.TLS.CAFile, err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", cfg.TLS.CAFile)
+		}
+	}
+
+	return tlsConfig, nil
+}
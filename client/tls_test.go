@@ -0,0 +1,122 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jjmrocha/beast/config"
+)
+
+// writeClientCertificate creates a self-signed client certificate, returning the names of the files
+func writeClientCertificate(t *testing.T, dir string) (*x509.Certificate, string, string) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "beast"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Error creating certificate: %v", err)
+	}
+
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client.key")
+	ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+
+	cert, _ := x509.ParseCertificate(der)
+	return cert, certFile, keyFile
+}
+
+func TestMutualTLS(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	clientCert, certFile, keyFile := writeClientCertificate(t, dir)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  x509.NewCertPool(),
+	}
+	server.TLS.ClientCAs.AddCert(clientCert)
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(dir, "ca.pem")
+	ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600)
+
+	var tests = []struct {
+		tls      config.TLS
+		expected int
+	}{
+		{config.TLS{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "example.com"}, http.StatusOK},
+		{config.TLS{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, MinVersion: "1.3"}, http.StatusOK},
+		{config.TLS{CAFile: caFile, ServerName: "example.com"}, TLSFailure},
+		{config.TLS{CertFile: certFile, KeyFile: keyFile}, TLSFailure},
+		{config.TLS{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "other.com"}, TLSFailure},
+	}
+	// then
+	for i, test := range tests {
+		cfg := config.Default()
+		cfg.TLS = test.tls
+		client, err := NewClient(cfg, 1)
+		if err != nil {
+			t.Fatalf("Error not expected: %v", err)
+		}
+
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		result := client.Execute(context.Background(), &Request{native: req})
+		if result.StatusCode != test.expected {
+			t.Errorf("got %v (%v) expected %v for config %v", result.StatusCode, result.Error, test.expected, i)
+		}
+	}
+}
+
+func TestNewClientWithMissingFiles(t *testing.T) {
+	// given
+	var tests = []config.TLS{
+		{CAFile: "missing.pem"},
+		{CertFile: "missing.pem", KeyFile: "missing.key"},
+	}
+	// then
+	for i, test := range tests {
+		cfg := config.Default()
+		cfg.TLS = test
+		if _, err := NewClient(cfg, 1); err == nil {
+			t.Errorf("expected error for config %v", i)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Phases contains the time spent on each phase of a request,
+// the connection phases are zero when the request used a connection already open
+type Phases struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	// Time waiting for the response, since the connection was available until the first byte
+	FirstByte time.Duration
+	// Time reading the body, after the first byte
+	Download time.Duration
+}
+
+// progress records how far a request went and when, used to measure the phases
+// of the request and to find where it failed
+type progress struct {
+	mutex        sync.Mutex
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	tlsFailed    bool
+	gotConn      time.Time
+	firstByte    time.Time
+}
+
+func (p *progress) trace() *httptrace.ClientTrace {
+	// The callbacks can be called by other goroutines, e.g. while dialing
+	record := func(update func()) {
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		update()
+	}
+
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			record(func() { p.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			record(func() { p.dnsDone = time.Now() })
+		},
+		ConnectStart: func(string, string) {
+			record(func() {
+				// With several addresses the first attempt starts the phase
+				if p.connectStart.IsZero() {
+					p.connectStart = time.Now()
+				}
+			})
+		},
+		ConnectDone: func(string, string, error) {
+			record(func() { p.connectDone = time.Now() })
+		},
+		TLSHandshakeStart: func() {
+			record(func() { p.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			record(func() {
+				p.tlsDone = time.Now()
+				p.tlsFailed = err != nil
+			})
+		},
+		GotConn: func(httptrace.GotConnInfo) {
+			record(func() { p.gotConn = time.Now() })
+		},
+		GotFirstResponseByte: func() {
+			record(func() { p.firstByte = time.Now() })
+		},
+	}
+}
+
+// phases returns the time spent on the phases of the request, the body was read until end
+func (p *progress) phases(end time.Time) Phases {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return Phases{
+		DNS:       between(p.dnsStart, p.dnsDone),
+		Connect:   between(p.connectStart, p.connectDone),
+		TLS:       between(p.tlsStart, p.tlsDone),
+		FirstByte: between(p.gotConn, p.firstByte),
+		Download:  between(p.firstByte, end),
+	}
+}
+
+// between returns the time between start and end, zero if one of them didn't happen
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+
+	return end.Sub(start)
+}
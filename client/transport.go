@@ -0,0 +1,288 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+
+	"github.com/jjmrocha/beast/config"
+	"golang.org/x/net/http2"
+)
+
+// newTransport creates the transport for the protocol of the configuration
+func newTransport(cfg *config.Config, parallelConns int) (http.RoundTripper, error) {
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Protocol {
+	case config.HTTP2:
+		return newHTTP2Transport(cfg, tlsConfig), nil
+	case config.H2C:
+		return &h2cTransport{
+			cleartext: newHTTP2Transport(cfg, nil),
+			tls:       newHTTPTransport(cfg, parallelConns, tlsConfig, true),
+		}, nil
+	}
+
+	return newHTTPTransport(cfg, parallelConns, tlsConfig, cfg.Protocol == config.AutoProtocol), nil
+}
+
+// newHTTPTransport creates a transport using HTTP/1.1, or HTTP/2 when attemptHTTP2 is true and the server supports it
+func newHTTPTransport(cfg *config.Config, parallelConns int, tlsConfig *tls.Config, attemptHTTP2 bool) *http.Transport {
+	maxIdleConns := cfg.GetMaxIdleConnections(parallelConns)
+	transport := &http.Transport{
+		DisableCompression:  cfg.DisableCompression,
+		DisableKeepAlives:   cfg.DisableKeepAlives,
+		MaxConnsPerHost:     cfg.MaxConnections,
+		MaxIdleConns:        maxIdleConns,
+		MaxIdleConnsPerHost: maxIdleConns,
+		TLSClientConfig:     tlsConfig,
+		ForceAttemptHTTP2:   attemptHTTP2,
+		Proxy:               proxyFunc(cfg.Proxy),
+	}
+
+	if !attemptHTTP2 {
+		// An empty map disables HTTP/2
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+
+	return transport
+}
+
+// newHTTP2Transport creates a transport that only uses HTTP/2, over TLS or cleartext (h2c) when tlsConfig is nil
+func newHTTP2Transport(cfg *config.Config, tlsConfig *tls.Config) *http2.Transport {
+	cleartext := tlsConfig == nil
+	transport := &http2.Transport{
+		TLSClientConfig:    tlsConfig,
+		DisableCompression: cfg.DisableCompression,
+		AllowHTTP:          cleartext,
+		DialTLSContext: func(ctx context.Context, network, addr string, tlsConfig *tls.Config) (net.Conn, error) {
+			if cleartext {
+				return dialHTTP2(ctx, addr, nil)
+			}
+
+			return dialHTTP2(ctx, addr, tlsConfig)
+		},
+	}
+
+	if cfg.StreamsPerConnection > 0 {
+		transport.ConnPool = &streamPool{
+			transport:  transport,
+			cleartext:  cleartext,
+			maxStreams: cfg.StreamsPerConnection,
+			conns:      make(map[string][]*http2.ClientConn),
+			dials:      make(map[string][]*dial),
+		}
+	}
+
+	return transport
+}
+
+// h2cTransport uses h2c for http URLs, https URLs (e.g. the token URL of oauth2) can't use h2c
+// and use HTTP/2 over TLS when the server supports it, otherwise HTTP/1.1
+type h2cTransport struct {
+	cleartext http.RoundTripper
+	tls       http.RoundTripper
+}
+
+func (t *h2cTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "https" {
+		return t.tls.RoundTrip(req)
+	}
+
+	return t.cleartext.RoundTrip(req)
+}
+
+// dialHTTP2 opens a connection for HTTP/2, cleartext when tlsConfig is nil,
+// the TLS handshake is reported to the client trace of ctx
+func dialHTTP2(ctx context.Context, addr string, tlsConfig *tls.Config) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil || tlsConfig == nil {
+		return conn, err
+	}
+
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+
+	tlsConn := tls.Client(conn, tlsConfig)
+	err = tlsConn.HandshakeContext(ctx)
+	state := tlsConn.ConnectionState()
+
+	if err == nil && state.NegotiatedProtocol != http2.NextProtoTLS {
+		err = fmt.Errorf("server at %s doesn't support HTTP/2", addr)
+	}
+
+	if trace != nil && trace.TLSHandshakeDone != nil {
+		trace.TLSHandshakeDone(state, err)
+	}
+
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return tlsConn, nil
+}
+
+// streamPool is a http2.ClientConnPool that opens a new connection when the connections
+// to the address already have maxStreams concurrent requests
+type streamPool struct {
+	transport  *http2.Transport
+	cleartext  bool
+	maxStreams int
+	mutex      sync.Mutex
+	conns      map[string][]*http2.ClientConn
+	dials      map[string][]*dial
+}
+
+// dial is a connection being opened, used by up to maxStreams requests
+type dial struct {
+	done     chan struct{}
+	requests int
+	reserved int
+	cc       *http2.ClientConn
+	err      error
+}
+
+// GetClientConn returns a connection to addr with a stream reserved for the request,
+// connections are opened without holding the lock, so requests don't wait for other dials
+func (p *streamPool) GetClientConn(req *http.Request, addr string) (*http2.ClientConn, error) {
+	p.mutex.Lock()
+
+	for _, cc := range p.conns[addr] {
+		state := cc.State()
+		if state.StreamsActive+state.StreamsReserved < p.maxStreams && cc.ReserveNewRequest() {
+			p.mutex.Unlock()
+			return cc, nil
+		}
+	}
+
+	for _, d := range p.dials[addr] {
+		if d.requests < p.maxStreams {
+			d.requests++
+			p.mutex.Unlock()
+			return p.wait(req, addr, d)
+		}
+	}
+
+	d := &dial{done: make(chan struct{}), requests: 1}
+	p.dials[addr] = append(p.dials[addr], d)
+	p.mutex.Unlock()
+
+	d.cc, d.err = p.connect(req, addr)
+
+	p.mutex.Lock()
+	dials := p.dials[addr]
+	for i := range dials {
+		if dials[i] == d {
+			p.dials[addr] = append(dials[:i], dials[i+1:]...)
+			break
+		}
+	}
+
+	if d.err == nil {
+		// Streams are reserved for all requests waiting for the connection
+		for d.reserved < d.requests && d.cc.ReserveNewRequest() {
+			d.reserved++
+		}
+
+		p.conns[addr] = append(p.conns[addr], d.cc)
+	}
+
+	p.mutex.Unlock()
+	close(d.done)
+
+	return p.wait(req, addr, d)
+}
+
+// wait returns the connection of d when it is open, trying again if it has no stream for the request
+func (p *streamPool) wait(req *http.Request, addr string, d *dial) (*http2.ClientConn, error) {
+	<-d.done
+
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	p.mutex.Lock()
+	reserved := d.reserved > 0
+	if reserved {
+		d.reserved--
+	}
+	p.mutex.Unlock()
+
+	if !reserved {
+		return p.GetClientConn(req, addr)
+	}
+
+	return d.cc, nil
+}
+
+func (p *streamPool) connect(req *http.Request, addr string) (*http2.ClientConn, error) {
+	conn, err := dialHTTP2(req.Context(), addr, p.tlsConfig(addr))
+	if err != nil {
+		return nil, err
+	}
+
+	cc, err := p.transport.NewClientConn(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return cc, nil
+}
+
+// MarkDead removes a connection that can't be used anymore
+func (p *streamPool) MarkDead(dead *http2.ClientConn) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for addr, conns := range p.conns {
+		for i, cc := range conns {
+			if cc == dead {
+				p.conns[addr] = append(conns[:i], conns[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (p *streamPool) tlsConfig(addr string) *tls.Config {
+	if p.cleartext {
+		return nil
+	}
+
+	tlsConfig := p.transport.TLSClientConfig.Clone()
+	tlsConfig.NextProtos = []string{http2.NextProtoTLS}
+
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName, _, _ = net.SplitHostPort(addr)
+	}
+
+	return tlsConfig
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jjmrocha/beast/config"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestProtocol(t *testing.T) {
+	// given
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h2Server := httptest.NewUnstartedServer(handler)
+	h2Server.EnableHTTP2 = true
+	h2Server.StartTLS()
+	defer h2Server.Close()
+
+	h1Server := httptest.NewTLSServer(handler)
+	defer h1Server.Close()
+
+	h2cServer := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	defer h2cServer.Close()
+
+	var tests = []struct {
+		protocol string
+		url      string
+		expected string
+	}{
+		{config.AutoProtocol, h2Server.URL, "HTTP/2.0"},
+		{config.AutoProtocol, h1Server.URL, "HTTP/1.1"},
+		{config.HTTP1, h2Server.URL, "HTTP/1.1"},
+		{"", h2Server.URL, "HTTP/1.1"},
+		{config.HTTP2, h2Server.URL, "HTTP/2.0"},
+		{config.HTTP2, h1Server.URL, ""},
+		{config.H2C, h2cServer.URL, "HTTP/2.0"},
+		{config.H2C, h2Server.URL, "HTTP/2.0"},
+		{config.H2C, h1Server.URL, "HTTP/1.1"},
+	}
+	// then
+	for _, test := range tests {
+		cfg := config.Default()
+		cfg.DisableCertificateCheck = true
+		cfg.Protocol = test.protocol
+		client, _ := NewClient(cfg, 1)
+
+		req, _ := http.NewRequest("GET", test.url, nil)
+		result := client.Execute(context.Background(), &Request{native: req})
+		if result.Protocol != test.expected {
+			t.Errorf("got %v (%v) expected %v using %v", result.Protocol, result.Error, test.expected, test.protocol)
+		}
+	}
+}
+
+func TestStreamsPerConnection(t *testing.T) {
+	// given
+	var mutex sync.Mutex
+	conns := make(map[string]bool)
+	server := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		conns[r.RemoteAddr] = true
+		mutex.Unlock()
+		time.Sleep(100 * time.Millisecond)
+	}), &http2.Server{}))
+	defer server.Close()
+
+	cfg := config.Default()
+	cfg.Protocol = config.H2C
+	cfg.StreamsPerConnection = 2
+	client, _ := NewClient(cfg, 6)
+	// when
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", server.URL, nil)
+			client.Execute(context.Background(), &Request{native: req})
+		}()
+	}
+
+	wg.Wait()
+	// then
+	if len(conns) != 3 {
+		t.Errorf("got %v connections expected 3", len(conns))
+	}
+}
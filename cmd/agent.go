@@ -0,0 +1,37 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"log"
+	"net"
+
+	"github.com/jjmrocha/beast/remote"
+)
+
+// Agent implements the `beast agent ...` command
+func Agent(address string) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		log.Fatalf("Error listening on %v: %v\n", address, err)
+	}
+
+	log.Printf("Agent waiting for coordinators on %v\n", listener.Addr())
+	if err := remote.Serve(listener); err != nil {
+		log.Fatalf("Error accepting connections: %v\n", err)
+	}
+}
@@ -25,10 +25,21 @@ Usage:
    beast [help]
    beast config <configFile>
    beast template [-m <http method>] [url] <templateFile>
-   beast run (-n <number of requests> | -t <test duration>)
-             [-c <number of concurrent requests>] 
+   beast run (-n <number of requests> | -t <test duration> | -i <iterations> | -stages <stages>)
+             [-c <number of concurrent requests>] [-rate <requests per second>] 
+             [-warmup <warm-up duration>]
+             [-abort-errors <percentage>] [-abort-latency <milliseconds>]
+             [-abort-window <number of requests>] [-abort-conn-errors <number of errors>]
              [-config <configFile>] [-data <dataFile>]
-             [-output <outputFile>] <templateFile>
+             [-output <outputFile>] [-agents <agent addresses>]
+             [-admin <address>] [-scenario] <templateFile>[:<weight>]...
+   beast agent [-listen <address>]
+   beast probe [-rate] [-start <load>] [-step <load>] [-max <load>]
+               [-d <step duration>] [-c <maximum requests in flight>]
+               [-p <percentile>] [-latency <milliseconds>] [-errors <percentage>]
+               [-config <configFile>] [-data <dataFile>] <templateFile>
+   beast replay [-speed <factor>] [-c <maximum requests in flight>]
+                [-config <configFile>] [-output <outputFile>] <replayFile>
 
 Where:
    config   Creates a file with the default parameters to setup HTTP connections
@@ -39,14 +50,85 @@ Where:
             url          string Endpoint to be tested
             templateFile string JSON/YAML file with details about the request to test
 
-   run      Executes a script and presents a report with execution results
+   run      Executes a script and presents a report with execution results,
+            if interrupted (Ctrl-C) waits up to 10 seconds for requests in flight
+            and reports the results of the executed requests
             -c           int    Number of concurrent requests (default 1)
+                                With "-rate" is the maximum number of requests in flight
             -n           int    Number of requests (can't be used with "-t")
             -t           int    Duration of the test in seconds (can't be used with "-n")
+            -i           int    Number of requests sent by each of the "-c" concurrent requests
+                                (virtual users), available to the templates as {{ .UserID }}
+                                and {{ .Iteration }} (can't be used with "-n", "-t" or "-rate")
+            -rate        float  Number of requests per second, requests are sent on a fixed
+                                timeline and response times include any queueing delay
+            -stages      string Changes the number of concurrent requests during the test,
+                                using the format "<duration>:<target>[,<duration>:<target>...]",
+                                e.g. "60s:50,5m:50,30s:0" or "@<file>" to read them from a file
+                                (can't be used with "-n", "-t", "-c" or "-rate")
+            -warmup      int    Initial seconds of the test excluded from stats, requests are
+                                written to the output file marked as warm-up
+            -abort-errors float Aborts the test when the percentage of failed requests
+                                in the window is above the value
+            -abort-latency int  Aborts the test when the p99 (in milliseconds) of the
+                                requests in the window is above the value
+            -abort-window int   Number of requests of the sliding window used by
+                                "-abort-errors" and "-abort-latency" (default 100)
+            -abort-conn-errors int
+                                Aborts the test after a number of consecutive connection errors
+                                (DNS failure, connection refused or reset, TLS failure or
+                                connect timeout), when a test is aborted the exit code is 2
             -config      string Config file to setup HTTP client
             -data        string CSV file with data for request generation
             -output      string CVS file with detailed execution results
-            templateFile string JSON/YAML file with details about the request to test 
+            -agents      string Comma separated list of agent addresses (host:port), the load
+                                and data are divided by the agents and results are merged
+            -admin       string TCP address of an HTTP API to change the test while running
+                                (e.g. ":9999", can't be used with "-agents"):
+                                POST /pause, POST /resume, POST /workers?value=<n>,
+                                POST /rate?value=<requests per second> and GET /stats
+            -scenario           The template file is a scenario, a JSON/YAML file with "steps"
+                                executed in order by each request, each step has a "template"
+                                file and an optional "extract" list with "variable", "from"
+                                (json, regex, header or cookie) and "expression", the values
+                                are available to the next steps as {{ .Vars.<variable> }}
+            templateFile string JSON/YAML file with details about the request to test, with several
+                                files each request uses one chosen by weight (default 1), e.g.
+                                "browse.json:70 search.json:25 checkout.json:5", and the results
+                                are also reported by template, templates can have "checks" that
+                                successful responses must pass: "status" (list, any status in it
+                                is successful, e.g. 302), "headers" (list with "key" and optional
+                                "value"), "body-contains", "body-regex", "json" (path: value) and
+                                "max-latency" (milliseconds)
+
+   agent    Waits for jobs from "beast run -agents ..." and executes them
+            -listen      string TCP address to listen for coordinators (default ":7070")
+
+   probe    Increases the load step by step until a limit is broken and reports the highest
+            load that was within the limits
+            -rate               Increase the request rate instead of the concurrent requests
+            -start       float  Load used on the first step, an integer without "-rate" (default 1)
+            -step        float  Load added on each step, an integer without "-rate" (default 1)
+            -max         float  Maximum load to test (default 100)
+            -d           int    Duration of each step in seconds (default 30)
+            -c           int    Maximum number of requests in flight when using "-rate" (default 100)
+            -p           int    Percentile used to check the latency limit (default 99)
+            -latency     int    Maximum latency in milliseconds for the percentile (default no limit)
+            -errors      float  Maximum percentage of non successful requests (default 1)
+            -config      string Config file to setup HTTP client
+            -data        string CSV file with data for request generation
+            templateFile string JSON/YAML file with details about the request to test
+
+   replay   Sends recorded requests again, keeping the original time between them
+            -speed       float  Factor applied to the original pace, e.g. 2 sends the requests
+                                twice as fast and 0.5 twice as slow (default 1)
+            -c           int    Maximum number of requests in flight (default 100)
+            -config      string Config file to setup HTTP client
+            -output      string CVS file with detailed execution results
+            replayFile   string Output file of "beast run -output ..." (extension ".csv"),
+                                only method and URL are replayed, or request log with one
+                                JSON object per line, with "timestamp" (RFC 3339), "method",
+                                "url" and optional "headers" and "body" (like templates)
 
 `
 
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/jjmrocha/beast/control"
+	"github.com/jjmrocha/beast/report"
+)
+
+// ProbeSteps defines how `beast probe` increases the load
+type ProbeSteps struct {
+	Start        float64
+	Step         float64
+	Max          float64
+	StepDuration int
+	// When true the steps change the request rate, otherwise the number of concurrent requests
+	Rate bool
+	// Maximum number of requests in flight when Rate is true
+	Concurrency int
+}
+
+func (p ProbeSteps) load(level float64) control.Load {
+	if p.Rate {
+		return control.Load{
+			Duration:    p.StepDuration,
+			Concurrency: p.Concurrency,
+			Rate:        level,
+		}
+	}
+
+	return control.Load{
+		Duration:    p.StepDuration,
+		Concurrency: int(level),
+	}
+}
+
+func (p ProbeSteps) describe(level float64) string {
+	if p.Rate {
+		return fmt.Sprintf("%v requests per second", level)
+	}
+
+	return fmt.Sprintf("%v concurrent requests", int(level))
+}
+
+// Probe implements the `beast probe ...` command
+func Probe(steps ProbeSteps, limits report.Limits, fileName, configFile, dataFile string) {
+	fmt.Printf("===== System =====\n")
+	fmt.Printf("Operating System: %v\n", runtime.GOOS)
+	fmt.Printf("System Architecture: %v\n", runtime.GOARCH)
+	fmt.Printf("Logical CPUs: %v\n", runtime.NumCPU())
+
+	fmt.Printf("===== Probe =====\n")
+	fmt.Printf("Request template: %v\n", fileName)
+	if dataFile != "" {
+		fmt.Printf("Sample Data: %v\n", dataFile)
+	}
+	if configFile != "" {
+		fmt.Printf("Configuration: %v\n", configFile)
+	}
+	fmt.Printf("Load: from %v to %v, adding %v every %v seconds\n", steps.describe(steps.Start), steps.describe(steps.Max), steps.Step, steps.StepDuration)
+	if limits.Latency > 0 {
+		fmt.Printf("Latency limit: p%v under %v\n", limits.Percentile, limits.Latency)
+	}
+	fmt.Printf("Error rate limit: %.2f%%\n", limits.ErrorRate)
+
+	fmt.Printf("===== Preparing =====\n")
+	cfg := readConfig(configFile)
+	httpClient := newClient(cfg, steps.load(steps.Max).MaxConcurrency())
+	tmpl := readTemplate(fileName)
+	data := readData(dataFile)
+
+	fmt.Printf("===== Executing =====\n")
+	var best float64
+	var reason string
+
+	for i := 0; steps.Start+float64(i)*steps.Step <= steps.Max; i++ {
+		level := steps.Start + float64(i)*steps.Step
+		load := steps.load(level)
+		configureLoad(&load, cfg)
+		ctrl := control.New(load)
+		ctrl.AsyncExecute(httpClient, tmpl, data)
+
+		// Without an output file there is nothing to fail
+		stats, _ := report.NewStats(noProgress{}, "", nil, 0)
+		for response := range ctrl.OutputChannel() {
+			stats.Update(response)
+		}
+
+		var ok bool
+		reason, ok = limits.Check(stats)
+		if !ok {
+			fmt.Printf("- %v: %v, failed with %v\n", steps.describe(level), limits.Summary(stats), reason)
+			break
+		}
+
+		fmt.Printf("- %v: %v, passed\n", steps.describe(level), limits.Summary(stats))
+		best = level
+	}
+
+	fmt.Printf("===== Probe Result =====\n")
+	if best == 0 {
+		fmt.Printf("No load was within the limits\n")
+	} else {
+		fmt.Printf("Highest load within the limits: %v\n", steps.describe(best))
+	}
+	if reason != "" {
+		fmt.Printf("Limit broken: %v\n", reason)
+	}
+}
+
+type noProgress struct{}
+
+func (noProgress) Update() {}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+
+	"github.com/jjmrocha/beast/control"
+	"github.com/jjmrocha/beast/replay"
+	"github.com/jjmrocha/beast/report"
+)
+
+// ReplayOptions contains the parameters of the `beast replay ...` command
+type ReplayOptions struct {
+	// Factor applied to the original pace, 2 sends the requests twice as fast
+	Speed float64
+	// Maximum number of requests in flight
+	Concurrency int
+	ReplayFile  string
+	ConfigFile  string
+	OutputFile  string
+}
+
+// Replay implements the `beast replay ...` command, returning the exit code
+func Replay(options ReplayOptions) int {
+	fmt.Printf("===== System =====\n")
+	fmt.Printf("Operating System: %v\n", runtime.GOOS)
+	fmt.Printf("System Architecture: %v\n", runtime.GOARCH)
+	fmt.Printf("Logical CPUs: %v\n", runtime.NumCPU())
+
+	fmt.Printf("===== Replay =====\n")
+	fmt.Printf("Recorded requests: %v\n", options.ReplayFile)
+	if options.ConfigFile != "" {
+		fmt.Printf("Configuration: %v\n", options.ConfigFile)
+	}
+	fmt.Printf("Speed: %vx\n", options.Speed)
+	fmt.Printf("Maximum concurrent requests: %v\n", options.Concurrency)
+
+	fmt.Printf("===== Preparing =====\n")
+	cfg := readConfig(options.ConfigFile)
+	fmt.Println("- Loading recorded requests")
+	entries, err := replay.Read(options.ReplayFile)
+	if err != nil {
+		log.Fatalf("Error loading recorded requests: %v\n", err)
+	}
+
+	last := entries[len(entries)-1].Offset
+	fmt.Printf("- %v requests recorded in %v\n", len(entries), last)
+
+	load := control.Load{
+		Requests:    len(entries),
+		Concurrency: options.Concurrency,
+	}
+	httpClient := newClient(cfg, load.Concurrency)
+	stats, err := report.NewStats(report.NewBar(load.Requests, 0), options.OutputFile, nil, 0)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Printf("===== Executing =====\n")
+	ctrl := control.New(load)
+	ctrl.AsyncReplay(httpClient, entries, options.Speed)
+
+	exitCode := collect(ctrl, stats, report.NewMonitor(report.AbortCriteria{}))
+	stats.PrintStats()
+	return exitCode
+}
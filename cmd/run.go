@@ -17,60 +17,262 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
 	"runtime"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/jjmrocha/beast/admin"
 	"github.com/jjmrocha/beast/client"
 	"github.com/jjmrocha/beast/config"
 	"github.com/jjmrocha/beast/control"
 	"github.com/jjmrocha/beast/data"
+	"github.com/jjmrocha/beast/loadtest"
+	"github.com/jjmrocha/beast/remote"
 	"github.com/jjmrocha/beast/report"
 	"github.com/jjmrocha/beast/template"
 )
 
-// Run implements the `beast run ...` command
-func Run(nRequests, tDuration int, nParallel int, fileName, configFile, dataFile string, outputFile string) {
+// Exit codes returned by Run
+const (
+	ExitCompleted   = 0
+	ExitAborted     = 2
+	ExitInterrupted = 130
+)
+
+// RunOptions contains the parameters of the `beast run ...` command
+type RunOptions struct {
+	Load control.Load
+	// Initial seconds of the test excluded from stats
+	Warmup int
+	Abort  report.AbortCriteria
+	// Addresses of the agents, when present the test is executed by the agents
+	Agents []string
+	// TCP address of the admin API, used to change the test while running
+	Admin string
+	// When true the template files are scenario files
+	Scenario bool
+	// Template files, with more than one each request uses a template chosen by weight
+	Templates  []WeightedFile
+	ConfigFile string
+	DataFile   string
+	OutputFile string
+}
+
+// WeightedFile is a template file and its weight in a mix of templates
+type WeightedFile struct {
+	FileName string
+	Weight   int
+}
+
+// Run implements the `beast run ...` command, returning the exit code
+func Run(options RunOptions) int {
+	load := options.Load
+	criteria := options.Abort
+
 	fmt.Printf("===== System =====\n")
 	fmt.Printf("Operating System: %v\n", runtime.GOOS)
 	fmt.Printf("System Architecture: %v\n", runtime.GOARCH)
 	fmt.Printf("Logical CPUs: %v\n", runtime.NumCPU())
 
 	fmt.Printf("===== Test =====\n")
-	fmt.Printf("Request template: %v\n", fileName)
-	if dataFile != "" {
-		fmt.Printf("Sample Data: %v\n", dataFile)
+	description := "Request template"
+	if options.Scenario {
+		description = "Scenario"
+	}
+	for _, file := range options.Templates {
+		if len(options.Templates) > 1 {
+			fmt.Printf("%v: %v (weight %v)\n", description, file.FileName, file.Weight)
+		} else {
+			fmt.Printf("%v: %v\n", description, file.FileName)
+		}
+	}
+	if options.DataFile != "" {
+		fmt.Printf("Sample Data: %v\n", options.DataFile)
+	}
+	if options.ConfigFile != "" {
+		fmt.Printf("Configuration: %v\n", options.ConfigFile)
+	}
+	if len(options.Agents) > 0 {
+		fmt.Printf("Agents: %v\n", strings.Join(options.Agents, ", "))
 	}
-	if configFile != "" {
-		fmt.Printf("Configuration: %v\n", configFile)
+	if load.Iterations > 0 {
+		fmt.Printf("Iterations per concurrent request: %v\n", load.Iterations)
+	} else if load.Requests > 0 {
+		fmt.Printf("Number of requests: %v\n", load.Requests)
+	} else {
+		fmt.Printf("Test duration: %v seconds\n", load.TestDuration())
 	}
-	if nRequests > 0 {
-		fmt.Printf("Number of requests: %v\n", nRequests)
+	if load.Rate > 0 {
+		fmt.Printf("Request rate: %v requests per second\n", load.Rate)
+		fmt.Printf("Maximum concurrent requests: %v\n", load.Concurrency)
+	} else if len(load.Stages) > 0 {
+		for i, stage := range load.Stages {
+			fmt.Printf("Stage %v: %v\n", i+1, stage)
+		}
 	} else {
-		fmt.Printf("Test duration: %v seconds\n", tDuration)
+		fmt.Printf("Number of concurrent requests: %v\n", load.Concurrency)
+	}
+	if options.Warmup > 0 {
+		fmt.Printf("Warm-up: %v seconds\n", options.Warmup)
+	}
+	if criteria.ErrorRate > 0 {
+		fmt.Printf("Abort when more than %v%% of the last %v requests fail\n", criteria.ErrorRate, criteria.Window)
+	}
+	if criteria.Latency > 0 {
+		fmt.Printf("Abort when p99 of the last %v requests is above %v\n", criteria.Window, criteria.Latency)
+	}
+	if criteria.ConnectionErrors > 0 {
+		fmt.Printf("Abort after %v consecutive connection errors\n", criteria.ConnectionErrors)
+	}
+	if options.Admin != "" {
+		fmt.Printf("Admin API: %v\n", options.Admin)
 	}
-	fmt.Printf("Number of concurrent requests: %v\n", nParallel)
 
 	fmt.Printf("===== Preparing =====\n")
-	httpClient := createHTTPClient(configFile, nParallel)
-	tmpl := readTemplate(fileName)
-	data := readData(dataFile)
+	cfg := readConfig(options.ConfigFile)
+	configureLoad(&load, cfg)
+	mix := readMix(options.Templates, options.Scenario)
+	data := readData(options.DataFile)
 
-	fmt.Printf("===== Executing =====\n")
-	ctrl := control.New(nRequests, tDuration, nParallel)
-	ctrl.AsyncExecute(httpClient, tmpl, data)
+	warmup := time.Duration(options.Warmup) * time.Second
 
-	stats := report.NewStats(nParallel, report.NewBar(nRequests, tDuration), outputFile)
-	for response := range ctrl.OutputChannel() {
-		stats.Update(response)
+	var exec loadtest.Execution
+	var start func()
+	if len(options.Agents) > 0 {
+		exec, start = prepareCluster(options.Agents, load, warmup, cfg, mix, data)
+	} else {
+		exec, start = prepareLocal(load, cfg, mix, data)
+	}
+
+	// The output file and the admin API are created before the test starts, so they can't fail while executing,
+	// with scenarios each request executes all steps
+	requests := mix.Requests(load.TotalRequests())
+	stats, err := report.NewStats(report.NewBar(requests, load.TestDuration()), options.OutputFile, load.Stages, warmup)
+	if err != nil {
+		log.Fatalln(err)
 	}
 
+	if len(options.Agents) > 0 {
+		stats.UseAgentWarmup()
+	}
+
+	if target, ok := exec.(admin.Target); ok && options.Admin != "" {
+		if err := admin.Start(options.Admin, target, stats); err != nil {
+			log.Fatalf("Error starting admin API: %v\n", err)
+		}
+	}
+
+	fmt.Printf("===== Executing =====\n")
+	start()
+
+	exitCode := collect(exec, stats, report.NewMonitor(criteria))
 	stats.PrintStats()
+	return exitCode
+}
+
+// prepareLocal creates the execution and the function that starts it
+func prepareLocal(load control.Load, cfg *config.Config, mix *template.Mix, rows *data.Data) (loadtest.Execution, func()) {
+	httpClient := newClient(cfg, load.MaxConcurrency())
+	compiled, err := mix.Compile()
+	if err != nil {
+		log.Fatalf("Error compiling template: %v\n", err)
+	}
+
+	ctrl := control.New(load)
+	start := func() {
+		ctrl.AsyncExecute(httpClient, compiled, rows)
+	}
+
+	return ctrl, start
+}
+
+// prepareCluster sends the jobs to the agents, returning the execution and the function that starts the agents
+func prepareCluster(agents []string, load control.Load, warmup time.Duration, cfg *config.Config, mix *template.Mix, rows *data.Data) (loadtest.Execution, func()) {
+	fmt.Println("- Connecting to agents")
+	cluster, err := remote.Connect(agents)
+	if err != nil {
+		log.Fatalf("Error connecting to agents: %v\n", err)
+	}
+
+	if err := cluster.Prepare(mix, cfg, rows, load, warmup); err != nil {
+		log.Fatalf("Error preparing agents: %v\n", err)
+	}
+
+	start := func() {
+		if err := cluster.Start(); err != nil {
+			log.Fatalf("Error starting agents: %v\n", err)
+		}
+	}
+
+	return cluster, start
+}
+
+// Time to wait for requests in flight after an interruption
+const gracePeriod = 10 * time.Second
+
+// collect updates the stats with the responses until the test ends and returns the exit code,
+// on SIGINT/SIGTERM or when the monitor aborts the test, the test is stopped and requests
+// in flight have gracePeriod to complete before being cancelled, a second signal terminates the process
+func collect(exec loadtest.Execution, stats *report.Stats, monitor *report.Monitor) int {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	finished := make(chan bool)
+	defer close(finished)
+
+	go func() {
+		for interrupted := false; ; interrupted = true {
+			select {
+			case <-signals:
+				if interrupted {
+					log.Fatalln("Test terminated")
+				}
+
+				cancel()
+			case <-finished:
+				return
+			}
+		}
+	}()
+
+	collector := &loadtest.Collector{
+		Stats:        stats,
+		Monitor:      monitor,
+		GracePeriod:  gracePeriod,
+		CancelReason: "interrupted by the user",
+		OnStop: func(reason string) {
+			log.Printf("Test stopped (%v), waiting up to %v for requests in flight\n", reason, gracePeriod)
+		},
+		OnCancel: func() {
+			log.Printf("Requests in flight didn't complete in %v and will be cancelled\n", gracePeriod)
+		},
+	}
+
+	switch collector.Collect(ctx, exec) {
+	case loadtest.Aborted:
+		return ExitAborted
+	case loadtest.Cancelled:
+		return ExitInterrupted
+	}
+
+	return ExitCompleted
 }
 
-func createHTTPClient(configFile string, nParallel int) *client.Client {
-	cfg := readConfig(configFile)
-	return client.NewClient(cfg, nParallel)
+// configureLoad applies the settings of the config that change how the load is generated
+func configureLoad(load *control.Load, cfg *config.Config) {
+	load.ThinkTime = cfg.ThinkTime
+	load.Pacing = time.Duration(cfg.Pacing) * time.Millisecond
+	load.Sessions = cfg.Sessions
 }
 
 func readConfig(configFile string) *config.Config {
@@ -79,7 +281,21 @@ func readConfig(configFile string) *config.Config {
 	}
 
 	fmt.Println("- Reading configuration")
-	return config.Read(configFile)
+	cfg, err := config.Read(configFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	return cfg
+}
+
+func newClient(cfg *config.Config, parallelConns int) *client.Client {
+	httpClient, err := client.NewClient(cfg, parallelConns)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	return httpClient
 }
 
 func readData(dataFile string) *data.Data {
@@ -88,12 +304,62 @@ func readData(dataFile string) *data.Data {
 	}
 
 	fmt.Println("- Loading data file")
-	return data.Read(dataFile)
+	rows, err := data.Read(dataFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	return rows
+}
+
+// readMix reads the template files, when there is more than one the file names are used to report the results by template
+func readMix(files []WeightedFile, isScenario bool) *template.Mix {
+	mix := &template.Mix{}
+
+	for _, file := range files {
+		entry := template.MixEntry{
+			Weight:   file.Weight,
+			Scenario: readScenario(file.FileName, isScenario),
+		}
+
+		if len(files) > 1 {
+			entry.Name = file.FileName
+		}
+
+		mix.Entries = append(mix.Entries, entry)
+	}
+
+	return mix
+}
+
+// readScenario reads a scenario file, or a template file as a scenario with a single step
+func readScenario(fileName string, isScenario bool) *template.Scenario {
+	if !isScenario {
+		fmt.Println("- Loading request template")
+		tmpl, err := template.Read(fileName)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		return template.NewScenario(tmpl)
+	}
+
+	fmt.Println("- Loading scenario")
+	scenario, err := template.ReadScenario(fileName)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	return scenario
 }
 
 func readTemplate(fileName string) *template.CompiledTemplate {
 	fmt.Println("- Loading request template")
-	tmpl := template.Read(fileName)
+	tmpl, err := template.Read(fileName)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
 	tmplC, err := tmpl.Compile()
 	if err != nil {
 		log.Fatalf("Error compiling template: %v\n", err)
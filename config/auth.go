@@ -0,0 +1,68 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Authentication types supported by Auth
+const (
+	NoAuth     = "none"
+	BasicAuth  = "basic"
+	BearerAuth = "bearer"
+	// OAuth2 client credentials grant
+	OAuth2Auth = "oauth2"
+)
+
+// Auth defines the credentials added to every request without an "Authorization" header
+type Auth struct {
+	Type string `json:"type"`
+	// Credentials used by basic
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Token used by bearer
+	Token string `json:"token"`
+	// Token endpoint and credentials used by oauth2, the token is cached and refreshed before it expires
+	TokenURL     string   `json:"token-url"`
+	ClientID     string   `json:"client-id"`
+	ClientSecret string   `json:"client-secret"`
+	Scopes       []string `json:"scopes"`
+}
+
+func (a *Auth) check() error {
+	switch a.Type {
+	case "", NoAuth:
+	case BasicAuth:
+		if a.Username == "" {
+			return errors.New("invalid config, 'auth.username' is required by basic authentication")
+		}
+	case BearerAuth:
+		if a.Token == "" {
+			return errors.New("invalid config, 'auth.token' is required by bearer authentication")
+		}
+	case OAuth2Auth:
+		if a.TokenURL == "" || a.ClientID == "" {
+			return errors.New("invalid config, 'auth.token-url' and 'auth.client-id' are required by oauth2 authentication")
+		}
+	default:
+		return fmt.Errorf("invalid config, 'auth.type' must be one of: %s, %s, %s or %s", NoAuth, BasicAuth, BearerAuth, OAuth2Auth)
+	}
+
+	return nil
+}
@@ -19,19 +19,74 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"net/url"
 )
 
 // Config defines the structure a configuration file
 type Config struct {
-	DisableCompression      bool `json:"disable-compression"`
-	DisableKeepAlives       bool `json:"disable-keep-alives"`
-	MaxConnections          int  `json:"max-connections"`
-	MaxIdleConnections      int  `json:"max-idle-connections"`
-	RequestTimeout          int  `json:"request-timeout"`
-	DisableCertificateCheck bool `json:"disable-certificate-check"`
-	DisableRedirects        bool `json:"disable-redirects"`
+	DisableCompression      bool      `json:"disable-compression"`
+	DisableKeepAlives       bool      `json:"disable-keep-alives"`
+	MaxConnections          int       `json:"max-connections"`
+	MaxIdleConnections      int       `json:"max-idle-connections"`
+	RequestTimeout          int       `json:"request-timeout"`
+	DisableCertificateCheck bool      `json:"disable-certificate-check"`
+	DisableRedirects        bool      `json:"disable-redirects"`
+	ThinkTime               ThinkTime `json:"think-time"`
+	Pacing                  int       `json:"pacing"`
+	Sessions                bool      `json:"sessions"`
+	Protocol                string    `json:"protocol"`
+	StreamsPerConnection    int       `json:"streams-per-connection"`
+	Proxy                   Proxy     `json:"proxy"`
+	TLS                     TLS       `json:"tls"`
+	Auth                    Auth      `json:"auth"`
+	Signing                 Signing   `json:"signing"`
+}
+
+// Proxy defines the proxy used by the HTTP client, not supported by the http2 and h2c protocols
+type Proxy struct {
+	// URL of the proxy using the scheme http, https (both use CONNECT for HTTPS requests) or socks5,
+	// when empty the environment variables HTTP_PROXY, HTTPS_PROXY and NO_PROXY are used
+	URL      string `json:"url"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Hosts accessed without proxy, using the format of NO_PROXY, e.g. "api.example.com",
+	// ".example.com" (subdomains), "10.0.0.0/8" or "*"
+	Bypass []string `json:"bypass"`
+}
+
+// Protocols supported by the HTTP client
+const (
+	// HTTP/2 when the server supports it (negotiated using TLS ALPN), otherwise HTTP/1.1
+	AutoProtocol = "auto"
+	// HTTP/1.1, the default, also used when the protocol is empty
+	HTTP1 = "http1"
+	// HTTP/2 over TLS, fails when the server doesn't support it
+	HTTP2 = "http2"
+	// HTTP/2 over cleartext TCP, using prior knowledge
+	H2C = "h2c"
+)
+
+// Distributions supported by ThinkTime, an empty distribution is the same as NoThinkTime
+const (
+	NoThinkTime          = "none"
+	FixedThinkTime       = "fixed"
+	UniformThinkTime     = "uniform"
+	NormalThinkTime      = "normal"
+	ExponentialThinkTime = "exponential"
+)
+
+// ThinkTime defines the time, in milliseconds, each concurrent request waits before sending the next request,
+// Min and Max (when not zero) limit the values generated by the normal and exponential distributions
+type ThinkTime struct {
+	Distribution string `json:"distribution"`
+	Min          int    `json:"min"`
+	Max          int    `json:"max"`
+	Mean         int    `json:"mean"`
+	StdDev       int    `json:"std-dev"`
 }
 
 // GetMaxIdleConnections if Config.MaxIdleConnections is zero resturns parallelConns else will return Config.MaxIdleConnections
@@ -52,34 +107,145 @@ func Default() *Config {
 		RequestTimeout:          30,
 		DisableCertificateCheck: false,
 		DisableRedirects:        true,
+		ThinkTime: ThinkTime{
+			Distribution: NoThinkTime,
+		},
+		Pacing:               0,
+		Sessions:             false,
+		Protocol:             HTTP1,
+		StreamsPerConnection: 0,
+		Auth: Auth{
+			Type: NoAuth,
+		},
+		Signing: Signing{
+			Type: NoSigning,
+		},
 	}
 }
 
 // Read reads the configuration from a file
-func Read(fileName string) *Config {
+func Read(fileName string) (*Config, error) {
 	data, err := ioutil.ReadFile(fileName)
 	if err != nil {
-		log.Fatalf("Error reading config file %s: %v\n", fileName, err)
+		return nil, fmt.Errorf("error reading config file %s: %v", fileName, err)
 	}
 
 	cfg := Default()
-	json.Unmarshal(data, cfg)
-	checkConfig(cfg)
-	return cfg
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", fileName, err)
+	}
+
+	if err := cfg.Check(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
 }
 
-func checkConfig(cfg *Config) {
-	if cfg.MaxConnections < 0 {
-		log.Fatalln("Invalid config, 'max-connections' must be zero or positive")
+// Check returns an error if the configuration is not valid
+func (c *Config) Check() error {
+	if c.MaxConnections < 0 {
+		return errors.New("invalid config, 'max-connections' must be zero or positive")
+	}
+
+	if c.MaxIdleConnections < 0 {
+		return errors.New("invalid config, 'max-idle-connections' must be zero or positive")
 	}
 
-	if cfg.MaxIdleConnections < 0 {
-		log.Fatalln("Invalid config, 'max-idle-connections' must be zero or positive")
+	if c.RequestTimeout < 0 {
+		return errors.New("invalid config, 'timeout' must be zero or positive")
 	}
 
-	if cfg.RequestTimeout < 0 {
-		log.Fatalln("Invalid config, 'timeout' must be zero or positive")
+	if c.Pacing < 0 {
+		return errors.New("invalid config, 'pacing' must be zero or positive")
 	}
+
+	switch c.Protocol {
+	case "", AutoProtocol, HTTP1, HTTP2, H2C:
+	default:
+		return fmt.Errorf("invalid config, 'protocol' must be one of: %s, %s, %s or %s", AutoProtocol, HTTP1, HTTP2, H2C)
+	}
+
+	if c.StreamsPerConnection < 0 {
+		return errors.New("invalid config, 'streams-per-connection' must be zero or positive")
+	}
+
+	if c.StreamsPerConnection > 0 && c.Protocol != HTTP2 && c.Protocol != H2C {
+		return fmt.Errorf("invalid config, 'streams-per-connection' can only be used with protocols %s and %s", HTTP2, H2C)
+	}
+
+	// The HTTP/2 transport doesn't limit the connections or close them after each request
+	if (c.Protocol == HTTP2 || c.Protocol == H2C) && (c.MaxConnections > 0 || c.DisableKeepAlives) {
+		return fmt.Errorf("invalid config, 'max-connections' and 'disable-keep-alives' can't be used with protocol %s", c.Protocol)
+	}
+
+	if err := c.checkProxy(); err != nil {
+		return err
+	}
+
+	if err := c.TLS.check(); err != nil {
+		return err
+	}
+
+	if err := c.Auth.check(); err != nil {
+		return err
+	}
+
+	if err := c.Signing.check(); err != nil {
+		return err
+	}
+
+	if c.Signing.Type == AWSSigning && c.Auth.Type != "" && c.Auth.Type != NoAuth {
+		return errors.New("invalid config, aws-sigv4 signing sets the 'Authorization' header and can't be used with 'auth'")
+	}
+
+	return checkThinkTime(&c.ThinkTime)
+}
+
+func (c *Config) checkProxy() error {
+	if c.Proxy.URL == "" {
+		if c.Proxy.Username != "" {
+			return errors.New("invalid config, 'proxy.username' requires 'proxy.url'")
+		}
+
+		return nil
+	}
+
+	if c.Protocol == HTTP2 || c.Protocol == H2C {
+		return fmt.Errorf("invalid config, 'proxy.url' can't be used with protocol %s", c.Protocol)
+	}
+
+	proxyURL, err := url.Parse(c.Proxy.URL)
+	if err != nil {
+		return fmt.Errorf("invalid config, 'proxy.url': %v", err)
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return errors.New("invalid config, 'proxy.url' scheme must be one of: http, https or socks5")
+	}
+
+	return nil
+}
+
+func checkThinkTime(thinkTime *ThinkTime) error {
+	switch thinkTime.Distribution {
+	case "", NoThinkTime, FixedThinkTime, UniformThinkTime, NormalThinkTime, ExponentialThinkTime:
+	default:
+		return fmt.Errorf("invalid config, 'think-time.distribution' must be one of: %s, %s, %s, %s or %s",
+			NoThinkTime, FixedThinkTime, UniformThinkTime, NormalThinkTime, ExponentialThinkTime)
+	}
+
+	if thinkTime.Min < 0 || thinkTime.Max < 0 || thinkTime.Mean < 0 || thinkTime.StdDev < 0 {
+		return errors.New("invalid config, 'think-time' values must be zero or positive")
+	}
+
+	if thinkTime.Max > 0 && thinkTime.Max < thinkTime.Min {
+		return errors.New("invalid config, 'think-time.max' must be greater or equal to 'think-time.min'")
+	}
+
+	return nil
 }
 
 // Write writes a configuration to a file
@@ -25,13 +25,89 @@ func TestRead(t *testing.T) {
 	// given
 	expectedConfig := Default()
 	// when
-	cfg := Read("../testdata/empty.json")
+	cfg, err := Read("../testdata/empty.json")
 	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
 	if !reflect.DeepEqual(cfg, expectedConfig) {
 		t.Errorf("got %v expected %v", cfg, expectedConfig)
 	}
 }
 
+func TestReadMissingFile(t *testing.T) {
+	// when
+	_, err := Read("../testdata/missing.json")
+	// then
+	if err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	// given
+	var tests = []struct {
+		change func(cfg *Config)
+		valid  bool
+	}{
+		{func(cfg *Config) {}, true},
+		{func(cfg *Config) { cfg.MaxConnections = -1 }, false},
+		{func(cfg *Config) { cfg.RequestTimeout = -1 }, false},
+		{func(cfg *Config) { cfg.ThinkTime.Distribution = "unknown" }, false},
+		{func(cfg *Config) { cfg.ThinkTime.Distribution = "" }, true},
+		{func(cfg *Config) { *cfg = Config{} }, true},
+		{func(cfg *Config) { cfg.Protocol = "spdy" }, false},
+		{func(cfg *Config) { cfg.Protocol = H2C; cfg.StreamsPerConnection = 10 }, true},
+		{func(cfg *Config) { cfg.StreamsPerConnection = -1 }, false},
+		{func(cfg *Config) { cfg.Proxy = Proxy{URL: "socks5://proxy:1080", Username: "user"} }, true},
+		{func(cfg *Config) { cfg.Proxy = Proxy{URL: "ftp://proxy:21"} }, false},
+		{func(cfg *Config) { cfg.Proxy = Proxy{Username: "user"} }, false},
+		{func(cfg *Config) { cfg.Proxy = Proxy{URL: "http://proxy:3128"}; cfg.Protocol = H2C }, false},
+		{func(cfg *Config) { cfg.TLS = TLS{MinVersion: "1.2", MaxVersion: "1.3"} }, true},
+		{func(cfg *Config) { cfg.TLS = TLS{MinVersion: "1.3", MaxVersion: "1.2"} }, false},
+		{func(cfg *Config) { cfg.TLS = TLS{MinVersion: "2.0"} }, false},
+		{func(cfg *Config) { cfg.TLS = TLS{CertFile: "client.pem"} }, false},
+		{func(cfg *Config) { cfg.TLS = TLS{CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}} }, true},
+		{func(cfg *Config) { cfg.TLS = TLS{CipherSuites: []string{"TLS_UNKNOWN"}} }, false},
+		{func(cfg *Config) { cfg.Auth = Auth{Type: BasicAuth, Username: "user"} }, true},
+		{func(cfg *Config) { cfg.Auth = Auth{Type: BearerAuth} }, false},
+		{func(cfg *Config) {
+			cfg.Auth = Auth{Type: OAuth2Auth, TokenURL: "https://auth/token", ClientID: "beast"}
+		}, true},
+		{func(cfg *Config) { cfg.Auth = Auth{Type: OAuth2Auth, ClientID: "beast"} }, false},
+		{func(cfg *Config) { cfg.Auth = Auth{Type: "digest"} }, false},
+		{func(cfg *Config) { cfg.Signing = Signing{Type: HMACSigning, KeyEnv: "API_KEY"} }, true},
+		{func(cfg *Config) { cfg.Signing = Signing{Type: HMACSigning, KeyEnv: "API_KEY", Algorithm: "md5"} }, false},
+		{func(cfg *Config) { cfg.Signing = Signing{Type: HMACSigning} }, false},
+		{func(cfg *Config) {
+			cfg.Signing = Signing{Type: AWSSigning, Region: "eu-west-1", Service: "execute-api"}
+		}, true},
+		{func(cfg *Config) { cfg.Signing = Signing{Type: AWSSigning, Region: "eu-west-1"} }, false},
+		{func(cfg *Config) {
+			cfg.Signing = Signing{Type: AWSSigning, Region: "eu-west-1", Service: "execute-api"}
+			cfg.Auth = Auth{Type: BearerAuth, Token: "abc"}
+		}, false},
+		{func(cfg *Config) { cfg.Signing = Signing{Type: "rsa"} }, false},
+		{func(cfg *Config) { cfg.Protocol = HTTP2; cfg.MaxConnections = 10 }, false},
+		{func(cfg *Config) { cfg.Protocol = H2C; cfg.DisableKeepAlives = true }, false},
+		{func(cfg *Config) { cfg.Protocol = HTTP1; cfg.MaxConnections = 10 }, true},
+		{func(cfg *Config) { cfg.Protocol = H2C; cfg.StreamsPerConnection = 10 }, true},
+		{func(cfg *Config) { cfg.Protocol = AutoProtocol; cfg.StreamsPerConnection = 10 }, false},
+		{func(cfg *Config) { cfg.Protocol = ""; cfg.StreamsPerConnection = 10 }, false},
+		{func(cfg *Config) { cfg.ThinkTime = ThinkTime{Distribution: UniformThinkTime, Min: 10, Max: 5} }, false},
+	}
+	// then
+	for i, test := range tests {
+		cfg := Default()
+		test.change(cfg)
+		err := cfg.Check()
+		if (err == nil) != test.valid {
+			t.Errorf("got %v for config %v", err, i)
+		}
+	}
+}
+
 func TestGetMaxIdleConnections(t *testing.T) {
 	// given
 	cfg := Default()
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Signing types supported by Signing
+const (
+	NoSigning   = "none"
+	HMACSigning = "hmac"
+	// AWS Signature Version 4
+	AWSSigning = "aws-sigv4"
+)
+
+// Hash algorithms supported by HMAC signing
+const (
+	SHA256 = "sha256"
+	SHA512 = "sha512"
+)
+
+// Signing defines the signature added to every request, the keys are read from environment variables
+// when the client is created, so each agent of a distributed test uses its own environment
+type Signing struct {
+	Type string `json:"type"`
+	// Environment variable with the secret key used by hmac
+	KeyEnv string `json:"key-env"`
+	// Hash used by hmac, sha256 when empty
+	Algorithm string `json:"algorithm"`
+	// Headers used by hmac for the signature (hex encoded) and the timestamp (Unix seconds),
+	// "X-Signature" and "X-Timestamp" when empty
+	Header          string `json:"header"`
+	TimestampHeader string `json:"timestamp-header"`
+	// Region and service used by aws-sigv4, the credentials are read from
+	// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and the optional AWS_SESSION_TOKEN
+	Region  string `json:"region"`
+	Service string `json:"service"`
+}
+
+func (s *Signing) check() error {
+	switch s.Type {
+	case "", NoSigning:
+	case HMACSigning:
+		if s.KeyEnv == "" {
+			return errors.New("invalid config, 'signing.key-env' is required by hmac signing")
+		}
+
+		switch s.Algorithm {
+		case "", SHA256, SHA512:
+		default:
+			return fmt.Errorf("invalid config, 'signing.algorithm' must be one of: %s or %s", SHA256, SHA512)
+		}
+	case AWSSigning:
+		if s.Region == "" || s.Service == "" {
+			return errors.New("invalid config, 'signing.region' and 'signing.service' are required by aws-sigv4 signing")
+		}
+	default:
+		return fmt.Errorf("invalid config, 'signing.type' must be one of: %s, %s or %s", NoSigning, HMACSigning, AWSSigning)
+	}
+
+	return nil
+}
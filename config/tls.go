@@ -0,0 +1,110 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// TLS defines the TLS settings of the HTTP client, the file names are relative to the working directory
+type TLS struct {
+	// PEM files with the client certificate and its private key, used for mutual TLS
+	CertFile string `json:"cert-file"`
+	KeyFile  string `json:"key-file"`
+	// PEM file with the certificates of the authorities trusted to verify servers, the system ones when empty
+	CAFile string `json:"ca-file"`
+	// Name used to verify the server certificate (and sent as SNI), the host of the request when empty
+	ServerName string `json:"server-name"`
+	// Versions "1.0", "1.1", "1.2" or "1.3", the defaults of Go when empty
+	MinVersion string `json:"min-version"`
+	MaxVersion string `json:"max-version"`
+	// Names of the cipher suites allowed up to TLS 1.2, e.g. "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+	// the TLS 1.3 suites aren't configurable
+	CipherSuites []string `json:"cipher-suites"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSVersion returns the crypto/tls value of the version, zero for an empty version
+func TLSVersion(version string) (uint16, error) {
+	if version == "" {
+		return 0, nil
+	}
+
+	value, present := tlsVersions[version]
+	if !present {
+		return 0, fmt.Errorf("unknown TLS version %v", version)
+	}
+
+	return value, nil
+}
+
+// CipherSuites returns the crypto/tls IDs of the cipher suites
+func CipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	ids := make(map[string]uint16)
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		ids[suite.Name] = suite.ID
+	}
+
+	suites := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, present := ids[name]
+		if !present {
+			return nil, fmt.Errorf("unknown cipher suite %v", name)
+		}
+
+		suites = append(suites, id)
+	}
+
+	return suites, nil
+}
+
+func (t *TLS) check() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("invalid config, 'tls.cert-file' and 'tls.key-file' must be used together")
+	}
+
+	min, err := TLSVersion(t.MinVersion)
+	if err != nil {
+		return fmt.Errorf("invalid config, 'tls.min-version': %v", err)
+	}
+
+	max, err := TLSVersion(t.MaxVersion)
+	if err != nil {
+		return fmt.Errorf("invalid config, 'tls.max-version': %v", err)
+	}
+
+	if min > 0 && max > 0 && max < min {
+		return fmt.Errorf("invalid config, 'tls.max-version' must be greater or equal to 'tls.min-version'")
+	}
+
+	if _, err := CipherSuites(t.CipherSuites); err != nil {
+		return fmt.Errorf("invalid config, 'tls.cipher-suites': %v", err)
+	}
+
+	return nil
+}
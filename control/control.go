@@ -18,8 +18,10 @@
 package control
 
 import (
+	"context"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jjmrocha/beast/client"
@@ -27,26 +29,85 @@ import (
 	"github.com/jjmrocha/beast/template"
 )
 
+// requestSource creates the requests sent by the workers
+type requestSource interface {
+	Request() (*client.Request, error)
+	Name() string
+	Log() string
+}
+
+// chainedSource is a requestSource with several requests executed in sequence (scenarios),
+// Next returns the request following the response, or nil after the last one
+type chainedSource interface {
+	requestSource
+	Next(response *client.Response) (*client.Request, error)
+}
+
+// checkedSource is a requestSource with checks to verify on the responses
+type checkedSource interface {
+	Verify(response *client.Response)
+}
+
+// userSource is a requestSource that knows the virtual user sending the request
+type userSource interface {
+	ForUser(userID, iteration int)
+}
+
+// sessionSource is a requestSource that can use the variables of a session
+type sessionSource interface {
+	UseVars(vars map[string]string)
+}
+
+// session is the state kept by a worker between requests when Load.Sessions is set
+type session struct {
+	httpClient *client.Client
+	vars       map[string]string
+}
+
+// prepared is a request ready to be sent by a worker
+type prepared struct {
+	request *client.Request
+	source  requestSource
+}
+
 // Control is used to control the execution of multiple goroutines
 type Control struct {
-	wg                 sync.WaitGroup
-	generatorChannel   chan *template.Generator
-	outputChannel      chan *client.Response
-	requestCount       int
-	executionDuration  int
-	concurrentRoutines int
+	wg               sync.WaitGroup
+	generatorChannel chan requestSource
+	outputChannel    chan *client.Response
+	load             Load
+	httpClient       *client.Client
+	mutex            sync.Mutex
+	workers          []chan bool
+	rate             float64
+	resumed          chan bool
+	finished         bool
+	replaying        bool
+	currentStage     int32
+	stop             chan bool
+	stopOnce         sync.Once
+	ctx              context.Context
+	cancel           context.CancelFunc
 }
 
-// New creates a control.Controle
-func New(nRequests, tDuration, nParallel int) *Control {
+// New creates a control.Controle,
+// when load.Rate is positive requests are scheduled at load.Rate requests per second (open model)
+// and load.Concurrency limits the number of requests in flight
+func New(load Load) *Control {
+	maxConcurrency := load.MaxConcurrency()
+	ctx, cancel := context.WithCancel(context.Background())
 	ctrl := &Control{
-		generatorChannel:   make(chan *template.Generator, nParallel),
-		outputChannel:      make(chan *client.Response, nParallel),
-		requestCount:       nRequests,
-		executionDuration:  tDuration,
-		concurrentRoutines: nParallel,
+		generatorChannel: make(chan requestSource, maxConcurrency),
+		outputChannel:    make(chan *client.Response, maxConcurrency),
+		load:             load,
+		rate:             load.Rate,
+		stop:             make(chan bool),
+		ctx:              ctx,
+		cancel:           cancel,
 	}
-	ctrl.wg.Add(nParallel)
+	// Released when the generation of requests ends,
+	// so the output channel is not closed before all workers are started
+	ctrl.wg.Add(1)
 
 	go func() {
 		ctrl.wg.Wait()
@@ -61,33 +122,139 @@ func (c *Control) OutputChannel() <-chan *client.Response {
 	return c.outputChannel
 }
 
+// Stop stops the generation of new requests, requests already sent will complete
+// and the output channel is closed when all finish
+func (c *Control) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+}
+
+// Cancel stops the test and cancels the requests in flight
+func (c *Control) Cancel() {
+	c.Stop()
+	c.cancel()
+}
+
+func (c *Control) isStopped() bool {
+	select {
+	case <-c.stop:
+		return true
+	case <-c.ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// sleepUntil waits until the time t, returning false if the control was stopped while waiting
+func (c *Control) sleepUntil(t time.Time) bool {
+	select {
+	case <-time.After(time.Until(t)):
+		return true
+	case <-c.stop:
+		return false
+	case <-c.ctx.Done():
+		return false
+	}
+}
+
 // AsyncExecute creates the goroutines and start the test execution
-func (c *Control) AsyncExecute(httpClient *client.Client, tmplc *template.CompiledTemplate, rows *data.Data) {
+func (c *Control) AsyncExecute(httpClient *client.Client, tmplc template.Executable, rows *data.Data) {
+	c.httpClient = httpClient
+
+	if len(c.load.Stages) == 0 {
+		c.setWorkers(c.load.Concurrency)
+	} else {
+		c.wg.Add(1)
+		go c.runStages()
+	}
+
 	go c.createGenerators(tmplc, rows)
+}
+
+// setWorkers starts or stops workers until there are n running, must be called with the mutex locked
+func (c *Control) setWorkers(n int) {
+	for len(c.workers) < n {
+		quit := make(chan bool)
+		c.workers = append(c.workers, quit)
+		c.wg.Add(1)
+
+		requestChannel := make(chan *prepared)
+		go c.makeRequest(requestChannel, quit, c.load.id(len(c.workers)))
+		go c.executeRequest(requestChannel)
+	}
+
+	for len(c.workers) > n {
+		last := len(c.workers) - 1
+		close(c.workers[last])
+		c.workers = c.workers[:last]
+	}
+}
+
+func (c *Control) workerCount() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.workers)
+}
+
+func (c *Control) runStages() {
+	defer c.wg.Done()
+
+	for i, stage := range c.load.Stages {
+		atomic.StoreInt32(&c.currentStage, int32(i))
+		stageStart := time.Now()
+		from := c.workerCount()
+		step := 1
+		if stage.Target < from {
+			step = -1
+		}
 
-	for i := 0; i < c.concurrentRoutines; i++ {
-		requestChannel := make(chan *client.Request)
-		go c.makeRequest(requestChannel)
-		go c.executeRequest(requestChannel, httpClient)
+		for j, offset := range stage.stepTimes(from) {
+			if !c.sleepUntil(stageStart.Add(offset)) {
+				return
+			}
+
+			c.mutex.Lock()
+			c.setWorkers(from + (j+1)*step)
+			c.mutex.Unlock()
+		}
+
+		if !c.sleepUntil(stageStart.Add(stage.Duration)) {
+			return
+		}
 	}
 }
 
+func (c *Control) stage() int {
+	return int(atomic.LoadInt32(&c.currentStage))
+}
+
 // Consts
 var emptyRecord = data.NewRecord()
-var generateError = client.Response{
-	Timestamp:  time.Now(),
-	StatusCode: -100,
+
+func generateError(source requestSource) *client.Response {
+	return &client.Response{
+		Timestamp:  time.Now(),
+		StatusCode: client.GenerationError,
+		Template:   source.Name(),
+	}
 }
 
-func (c *Control) createGenerators(tmplc *template.CompiledTemplate, rows *data.Data) {
-	requestCount := c.requestCount
+func (c *Control) createGenerators(tmplc template.Executable, rows *data.Data) {
+	defer c.wg.Done()
+	defer c.markFinished()
+
+	requestCount := c.load.TotalRequests()
 	if requestCount == 0 {
 		requestCount = int(^uint(0) >> 1) // Max int value
 	}
 
-	duration := c.executionDuration
-	if duration == 0 {
-		duration = 31536000 // One year
+	duration := time.Duration(c.load.Duration) * time.Second
+	if len(c.load.Stages) > 0 {
+		duration = c.load.stagesDuration()
+	} else if duration == 0 {
+		duration = 31536000 * time.Second // One year
 	}
 
 	nextRecord := func() *data.Record {
@@ -99,41 +266,175 @@ func (c *Control) createGenerators(tmplc *template.CompiledTemplate, rows *data.
 	}
 
 	defer close(c.generatorChannel)
-	timeout := time.After(time.Duration(duration) * time.Second)
+	timer := time.AfterFunc(duration, c.Cancel)
+	defer timer.Stop()
+
+	// The timeline restarts when the rate changes or the test is resumed
+	base := time.Now()
+	baseIndex := 0
+	rate := c.currentRate()
 
 	for i := 1; i <= requestCount; i++ {
+		running, resumed := c.waitWhilePaused()
+		if !running {
+			return
+		}
+
+		if newRate := c.currentRate(); resumed || newRate != rate {
+			if resumed {
+				base = time.Now()
+			} else if rate > 0 {
+				base = schedule(base, i-baseIndex, rate)
+			}
+
+			baseIndex = i - 1
+			rate = newRate
+		}
+
 		generator := &template.Generator{
 			Data:     nextRecord(),
-			RecordID: i,
+			RecordID: c.load.id(i),
 			Template: tmplc,
 		}
+
+		if rate > 0 {
+			generator.Scheduled = schedule(base, i-baseIndex, rate)
+			if !c.sleepUntil(generator.Scheduled) {
+				return
+			}
+		}
+
 		select {
 		case c.generatorChannel <- generator:
-		case <-timeout:
+		case <-c.stop:
+			return
+		case <-c.ctx.Done():
 			return
 		}
 	}
 }
 
-func (c *Control) makeRequest(requestChannel chan<- *client.Request) {
+// schedule returns the time when the request number i should be sent,
+// using a fixed timeline that doesn't depend on how long previous requests took
+func schedule(start time.Time, i int, rate float64) time.Time {
+	offset := float64(i-1) * float64(time.Second) / rate
+	return start.Add(time.Duration(offset))
+}
+
+// makeRequest prepares the requests of the worker userID, that stops after Load.Iterations when set
+func (c *Control) makeRequest(requestChannel chan<- *prepared, quit <-chan bool, userID int) {
 	defer close(requestChannel)
 
-	for generator := range c.generatorChannel {
+	for iteration := 1; c.load.Iterations == 0 || iteration <= c.load.Iterations; iteration++ {
+		var generator requestSource
+		var ok bool
+
+		select {
+		case generator, ok = <-c.generatorChannel:
+			if !ok || c.isStopped() {
+				return
+			}
+		case <-quit:
+			return
+		case <-c.stop:
+			return
+		}
+
+		if user, ok := generator.(userSource); ok {
+			user.ForUser(userID, iteration)
+		}
+
+		if c.load.Sessions {
+			// The request depends on the session of the worker, so it's generated by the worker
+			requestChannel <- &prepared{source: generator}
+			continue
+		}
+
 		req, err := generator.Request()
 		if err != nil {
 			log.Printf("Error generating request for %s: %v\n", generator.Log(), err)
-			c.outputChannel <- &generateError
+			c.outputChannel <- generateError(generator)
 			continue
 		}
 
-		requestChannel <- req
+		requestChannel <- &prepared{request: req, source: generator}
 	}
 }
 
-func (c *Control) executeRequest(requestChannel <-chan *client.Request, httpClient *client.Client) {
+func (c *Control) executeRequest(requestChannel <-chan *prepared) {
 	defer c.wg.Done()
 
-	for req := range requestChannel {
-		c.outputChannel <- httpClient.Execute(req)
+	var pause *wait
+	if c.load.Rate == 0 && !c.replaying {
+		pause = newWait(c.load.ThinkTime, c.load.Pacing)
+	}
+
+	sess := &session{httpClient: c.httpClient}
+	if c.load.Sessions {
+		sess.httpClient = c.httpClient.WithCookieJar()
+		sess.vars = make(map[string]string)
+	}
+
+	for item := range requestChannel {
+		// Requests prepared before the test was stopped are not sent
+		if c.isStopped() {
+			continue
+		}
+
+		iterationStart := time.Now()
+		stage := c.stage()
+		c.executeChain(item, stage, sess)
+
+		if pause != nil {
+			c.sleepUntil(pause.until(iterationStart))
+		}
+	}
+}
+
+// executeChain sends the request and, for scenarios, the requests of the following steps
+func (c *Control) executeChain(item *prepared, stage int, sess *session) {
+	chain, chained := item.source.(chainedSource)
+	req := item.request
+
+	if req == nil {
+		if source, ok := item.source.(sessionSource); ok && sess.vars != nil {
+			source.UseVars(sess.vars)
+		}
+
+		var err error
+		req, err = item.source.Request()
+		if err != nil {
+			log.Printf("Error generating request for %s: %v\n", item.source.Log(), err)
+			c.outputChannel <- generateError(item.source)
+			return
+		}
+	}
+
+	for req != nil {
+		response := sess.httpClient.Execute(c.ctx, req)
+		response.Stage = stage
+		response.Template = item.source.Name()
+
+		if checked, ok := item.source.(checkedSource); ok {
+			checked.Verify(response)
+		}
+
+		var next *client.Request
+		var err error
+		if chained && !c.isStopped() {
+			next, err = chain.Next(response)
+		}
+
+		// The body and headers are only needed to extract values
+		response.Body, response.Header = nil, nil
+		c.outputChannel <- response
+
+		if err != nil {
+			log.Printf("Error generating request for %s: %v\n", chain.Log(), err)
+			c.outputChannel <- generateError(chain)
+			return
+		}
+
+		req = next
 	}
 }
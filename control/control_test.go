@@ -0,0 +1,45 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	// given
+	start := time.Now()
+	var tests = []struct {
+		rate     float64
+		request  int
+		expected time.Duration
+	}{
+		{10, 1, 0},
+		{10, 2, 100 * time.Millisecond},
+		{10, 11, time.Second},
+		{0.5, 2, 2 * time.Second},
+		{3, 4, time.Second},
+	}
+	// then
+	for _, test := range tests {
+		result := schedule(start, test.request, test.rate).Sub(start)
+		if result != test.expected {
+			t.Errorf("got %v expected %v for request %v at rate %v", result, test.expected, test.request, test.rate)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"time"
+
+	"github.com/jjmrocha/beast/config"
+)
+
+// Load defines the load to be generated during a test
+type Load struct {
+	// Number of requests, zero for no limit
+	Requests int
+	// Duration of the test in seconds, zero for no limit
+	Duration int
+	// Number of concurrent requests, or maximum requests in flight when Rate is used
+	Concurrency int
+	// Number of requests sent by each concurrent request (virtual user), zero for no limit
+	Iterations int
+	// Number of requests per second, zero to send requests as fast as possible
+	Rate float64
+	// When present, the number of concurrent requests changes during the test
+	Stages []Stage
+	// Time each concurrent request waits before the next request, not used with Rate
+	ThinkTime config.ThinkTime
+	// When positive each concurrent request sends a request every Pacing, not used with Rate
+	Pacing time.Duration
+	// When true each concurrent request (virtual user) has its own cookies and template variables
+	Sessions bool
+	// Index and number of the parts when the load was divided by Split,
+	// the IDs of requests and virtual users are unique across the parts
+	Part  int
+	Parts int
+}
+
+// MaxConcurrency returns the highest number of concurrent requests used during the test
+func (l Load) MaxConcurrency() int {
+	if len(l.Stages) == 0 {
+		return l.Concurrency
+	}
+
+	max := 0
+
+	for _, stage := range l.Stages {
+		if stage.Target > max {
+			max = stage.Target
+		}
+	}
+
+	return max
+}
+
+// TotalRequests returns the number of requests of the test, zero if it is limited by duration
+func (l Load) TotalRequests() int {
+	if l.Iterations > 0 {
+		return l.Concurrency * l.Iterations
+	}
+
+	return l.Requests
+}
+
+// TestDuration returns the duration of the test in seconds, zero if it is limited by number of requests
+func (l Load) TestDuration() int {
+	if len(l.Stages) == 0 {
+		return l.Duration
+	}
+
+	total := l.stagesDuration()
+	return int((total + time.Second - 1) / time.Second)
+}
+
+func (l Load) stagesDuration() time.Duration {
+	var total time.Duration
+
+	for _, stage := range l.Stages {
+		total += stage.Duration
+	}
+
+	return total
+}
+
+// Split divides the load into n loads that together generate the same load,
+// used to distribute the test by several agents
+func (l Load) Split(n int) []Load {
+	loads := make([]Load, 0, n)
+
+	for i := 0; i < n; i++ {
+		load := l
+		load.Requests = share(l.Requests, n, i)
+		load.Concurrency = share(l.Concurrency, n, i)
+		load.Rate = l.Rate / float64(n)
+		load.Part = i
+		load.Parts = n
+
+		if len(l.Stages) > 0 {
+			load.Stages = make([]Stage, 0, len(l.Stages))
+
+			for _, stage := range l.Stages {
+				load.Stages = append(load.Stages, Stage{
+					Duration: stage.Duration,
+					Target:   share(stage.Target, n, i),
+				})
+			}
+		}
+
+		loads = append(loads, load)
+	}
+
+	return loads
+}
+
+// id returns the ID of the request or virtual user number n of the part,
+// the parts use interleaved IDs, e.g. with 2 parts the first uses 1, 3, 5... and the second 2, 4, 6...
+func (l Load) id(n int) int {
+	if l.Parts <= 1 {
+		return n
+	}
+
+	return (n-1)*l.Parts + l.Part + 1
+}
+
+// share returns the part i of value divided by n, the remainder goes to the first parts
+func share(value, n, i int) int {
+	part := value / n
+	if i < value%n {
+		part++
+	}
+
+	return part
+}
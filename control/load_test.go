@@ -0,0 +1,101 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadWithStages(t *testing.T) {
+	// given
+	load := Load{
+		Stages: []Stage{
+			{Duration: 1500 * time.Millisecond, Target: 10},
+			{Duration: time.Second, Target: 20},
+			{Duration: time.Second, Target: 0},
+		},
+	}
+	// then
+	if result := load.MaxConcurrency(); result != 20 {
+		t.Errorf("got %v expected %v for MaxConcurrency", result, 20)
+	}
+
+	if result := load.TestDuration(); result != 4 {
+		t.Errorf("got %v expected %v for TestDuration", result, 4)
+	}
+}
+
+func TestLoadTotalRequests(t *testing.T) {
+	// given
+	var tests = []struct {
+		load     Load
+		expected int
+	}{
+		{Load{Requests: 10, Concurrency: 2}, 10},
+		{Load{Concurrency: 3, Iterations: 5}, 15},
+		{Load{Duration: 10, Concurrency: 3}, 0},
+	}
+	// then
+	for _, test := range tests {
+		if result := test.load.TotalRequests(); result != test.expected {
+			t.Errorf("got %v expected %v for %+v", result, test.expected, test.load)
+		}
+	}
+}
+
+func TestLoadSplit(t *testing.T) {
+	// given
+	load := Load{
+		Requests:    10,
+		Concurrency: 5,
+		Rate:        30,
+		Stages: []Stage{
+			{Duration: time.Second, Target: 4},
+		},
+	}
+	expected := []Load{
+		{Requests: 4, Concurrency: 2, Rate: 10, Stages: []Stage{{Duration: time.Second, Target: 2}}, Part: 0, Parts: 3},
+		{Requests: 3, Concurrency: 2, Rate: 10, Stages: []Stage{{Duration: time.Second, Target: 1}}, Part: 1, Parts: 3},
+		{Requests: 3, Concurrency: 1, Rate: 10, Stages: []Stage{{Duration: time.Second, Target: 1}}, Part: 2, Parts: 3},
+	}
+	// when
+	result := load.Split(3)
+	// then
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("got %v expected %v", result, expected)
+	}
+}
+
+func TestLoadSplitIDs(t *testing.T) {
+	// given
+	load := Load{Requests: 10, Concurrency: 5}
+	ids := make(map[int]bool)
+	// when
+	for _, part := range load.Split(3) {
+		for n := 1; n <= part.Requests; n++ {
+			ids[part.id(n)] = true
+		}
+	}
+	// then
+	for id := 1; id <= load.Requests; id++ {
+		if !ids[id] {
+			t.Errorf("got %v expected ID %v", ids, id)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jjmrocha/beast/client"
+	"github.com/jjmrocha/beast/replay"
+)
+
+type replayed struct {
+	entry     *replay.Entry
+	scheduled time.Time
+}
+
+func (r *replayed) Request() (*client.Request, error) {
+	req, err := r.entry.Request.Request()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Scheduled = r.scheduled
+	return req, nil
+}
+
+func (r *replayed) Name() string {
+	return ""
+}
+
+func (r *replayed) Log() string {
+	return fmt.Sprintf("recorded request at %v: %v %v", r.entry.Offset, r.entry.Request.Method, r.entry.Request.Endpoint)
+}
+
+// AsyncReplay sends the recorded requests keeping the original time between them,
+// divided by speed, load.Concurrency limits the number of requests in flight
+func (c *Control) AsyncReplay(httpClient *client.Client, entries []replay.Entry, speed float64) {
+	c.httpClient = httpClient
+	c.replaying = true
+	c.setWorkers(c.load.Concurrency)
+
+	go c.replayGenerators(entries, speed)
+}
+
+func (c *Control) replayGenerators(entries []replay.Entry, speed float64) {
+	defer c.wg.Done()
+	defer c.markFinished()
+	defer close(c.generatorChannel)
+
+	start := time.Now()
+
+	for i := range entries {
+		entry := &entries[i]
+		scheduled := start.Add(time.Duration(float64(entry.Offset) / speed))
+		if !c.sleepUntil(scheduled) {
+			return
+		}
+
+		select {
+		case c.generatorChannel <- &replayed{entry: entry, scheduled: scheduled}:
+		case <-c.stop:
+			return
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import "errors"
+
+// Status describes the current state of a test in execution
+type Status struct {
+	Paused  bool    `json:"paused"`
+	Workers int     `json:"workers"`
+	Rate    float64 `json:"rate"`
+}
+
+// Errors returned when changing a test in execution
+var (
+	ErrTestFinished    = errors.New("the test already finished")
+	ErrUsingStages     = errors.New("the number of concurrent requests is defined by the stages")
+	ErrUsingIterations = errors.New("the number of concurrent requests is fixed when using iterations")
+	ErrNotUsingRate    = errors.New("the test is not using a request rate")
+	ErrInvalidValue    = errors.New("invalid value")
+)
+
+// Status returns the current state of the test
+func (c *Control) Status() Status {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return Status{
+		Paused:  c.resumed != nil,
+		Workers: len(c.workers),
+		Rate:    c.rate,
+	}
+}
+
+// Pause suspends the generation of new requests until Resume is called,
+// the test duration is not extended by the time the test is paused
+func (c *Control) Pause() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.resumed == nil {
+		c.resumed = make(chan bool)
+	}
+}
+
+// Resume restarts the generation of requests after a Pause
+func (c *Control) Resume() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.resumed != nil {
+		close(c.resumed)
+		c.resumed = nil
+	}
+}
+
+// SetWorkers changes the number of concurrent requests (or requests in flight when using a rate)
+func (c *Control) SetWorkers(n int) error {
+	if n < 0 {
+		return ErrInvalidValue
+	}
+
+	if len(c.load.Stages) > 0 {
+		return ErrUsingStages
+	}
+
+	if c.load.Iterations > 0 {
+		return ErrUsingIterations
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.finished {
+		return ErrTestFinished
+	}
+
+	c.setWorkers(n)
+	return nil
+}
+
+// SetRate changes the number of requests per second
+func (c *Control) SetRate(rate float64) error {
+	if rate <= 0 {
+		return ErrInvalidValue
+	}
+
+	if c.load.Rate == 0 {
+		return ErrNotUsingRate
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.finished {
+		return ErrTestFinished
+	}
+
+	c.rate = rate
+	return nil
+}
+
+func (c *Control) currentRate() float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.rate
+}
+
+func (c *Control) markFinished() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.finished = true
+}
+
+// waitWhilePaused blocks while the test is paused, returning false for running if the test was stopped
+// and true for resumed if the test was paused
+func (c *Control) waitWhilePaused() (running bool, resumed bool) {
+	c.mutex.Lock()
+	waiting := c.resumed
+	c.mutex.Unlock()
+
+	if waiting == nil {
+		return true, false
+	}
+
+	select {
+	case <-waiting:
+		return true, true
+	case <-c.stop:
+		return false, false
+	case <-c.ctx.Done():
+		return false, false
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import "testing"
+
+func TestPauseAndResume(t *testing.T) {
+	// given
+	ctrl := New(Load{Requests: 1, Concurrency: 1})
+	// when
+	ctrl.Pause()
+	paused := ctrl.Status().Paused
+	ctrl.Resume()
+	// then
+	if !paused {
+		t.Error("expected the test to be paused")
+	}
+
+	if ctrl.Status().Paused {
+		t.Error("expected the test to be resumed")
+	}
+
+	if running, resumed := ctrl.waitWhilePaused(); !running || resumed {
+		t.Errorf("got running %v and resumed %v expected true and false", running, resumed)
+	}
+}
+
+func TestSetRate(t *testing.T) {
+	// given
+	var tests = []struct {
+		load     Load
+		rate     float64
+		expected error
+	}{
+		{Load{Duration: 1, Concurrency: 1, Rate: 10}, 20, nil},
+		{Load{Duration: 1, Concurrency: 1, Rate: 10}, 0, ErrInvalidValue},
+		{Load{Duration: 1, Concurrency: 1}, 20, ErrNotUsingRate},
+	}
+	// then
+	for _, test := range tests {
+		ctrl := New(test.load)
+		result := ctrl.SetRate(test.rate)
+		if result != test.expected {
+			t.Errorf("got %v expected %v for rate %v", result, test.expected, test.rate)
+		}
+	}
+}
+
+func TestSetWorkers(t *testing.T) {
+	// given
+	var tests = []struct {
+		load     Load
+		workers  int
+		expected error
+	}{
+		{Load{Duration: 1, Stages: []Stage{{Target: 1}}}, 2, ErrUsingStages},
+		{Load{Concurrency: 2, Iterations: 5}, 3, ErrUsingIterations},
+		{Load{Duration: 1, Concurrency: 1}, -1, ErrInvalidValue},
+	}
+	// then
+	for _, test := range tests {
+		ctrl := New(test.load)
+		result := ctrl.SetWorkers(test.workers)
+		if result != test.expected {
+			t.Errorf("got %v expected %v for %v workers", result, test.expected, test.workers)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stage is a period of the test where the number of concurrent requests
+// changes linearly from the value of the previous stage (zero for the first) to Target
+type Stage struct {
+	Duration time.Duration
+	Target   int
+}
+
+func (s Stage) String() string {
+	return fmt.Sprintf("reach %v concurrent requests in %v", s.Target, s.Duration)
+}
+
+// ParseStages reads a list of stages using the format "<duration>:<target>[,<duration>:<target>...]",
+// when spec starts with "@" the stages are read from the file, one or more per line
+func ParseStages(spec string) ([]Stage, error) {
+	if strings.HasPrefix(spec, "@") {
+		fileName := spec[1:]
+		data, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("error reading stages file %s: %w", fileName, err)
+		}
+
+		spec = string(data)
+	}
+
+	stages := make([]Stage, 0)
+
+	for _, line := range strings.Split(spec, "\n") {
+		if pos := strings.Index(line, "#"); pos >= 0 {
+			line = line[:pos]
+		}
+
+		for _, field := range strings.Split(line, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+
+			stage, err := parseStage(field)
+			if err != nil {
+				return nil, err
+			}
+
+			stages = append(stages, stage)
+		}
+	}
+
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("no stages found in %q", spec)
+	}
+
+	return stages, nil
+}
+
+func parseStage(field string) (Stage, error) {
+	parts := strings.Split(field, ":")
+	if len(parts) != 2 {
+		return Stage{}, fmt.Errorf("invalid stage %q, expected <duration>:<target>", field)
+	}
+
+	duration, err := time.ParseDuration(strings.TrimSpace(parts[0]))
+	if err != nil || duration < 0 {
+		return Stage{}, fmt.Errorf("invalid duration on stage %q", field)
+	}
+
+	target, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || target < 0 {
+		return Stage{}, fmt.Errorf("invalid target on stage %q", field)
+	}
+
+	return Stage{Duration: duration, Target: target}, nil
+}
+
+// stepTimes returns the offsets, from the beginning of the stage,
+// where one concurrent request must be added or removed to move from "from" to the stage target
+func (s Stage) stepTimes(from int) []time.Duration {
+	changes := s.Target - from
+	if changes < 0 {
+		changes = -changes
+	}
+
+	steps := make([]time.Duration, 0, changes)
+
+	for i := 1; i <= changes; i++ {
+		offset := time.Duration(int64(s.Duration) * int64(i) / int64(changes))
+		steps = append(steps, offset)
+	}
+
+	return steps
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseStages(t *testing.T) {
+	// given
+	expected := []Stage{
+		{Duration: 10 * time.Second, Target: 5},
+		{Duration: time.Minute, Target: 5},
+		{Duration: 5 * time.Second, Target: 0},
+	}
+	var tests = []string{
+		"10s:5,1m:5,5s:0",
+		" 10s : 5 , 1m:5,5s:0 ",
+		"@../testdata/stages.txt",
+	}
+	// then
+	for _, test := range tests {
+		result, err := ParseStages(test)
+		if err != nil {
+			t.Errorf("Error not expected for %q: %v", test, err)
+		}
+
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("got %v expected %v for %q", result, expected, test)
+		}
+	}
+}
+
+func TestParseInvalidStages(t *testing.T) {
+	// given
+	var tests = []string{
+		"",
+		"10s",
+		"10:5",
+		"10s:-1",
+		"10s:x",
+		"@../testdata/missing.txt",
+	}
+	// then
+	for _, test := range tests {
+		if _, err := ParseStages(test); err == nil {
+			t.Errorf("Error expected for %q", test)
+		}
+	}
+}
+
+func TestStepTimes(t *testing.T) {
+	// given
+	var tests = []struct {
+		stage    Stage
+		from     int
+		expected []time.Duration
+	}{
+		{Stage{4 * time.Second, 4}, 0, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second}},
+		{Stage{time.Second, 0}, 2, []time.Duration{500 * time.Millisecond, time.Second}},
+		{Stage{time.Minute, 5}, 5, []time.Duration{}},
+		{Stage{0, 2}, 0, []time.Duration{0, 0}},
+	}
+	// then
+	for _, test := range tests {
+		result := test.stage.stepTimes(test.from)
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("got %v expected %v for %v from %v", result, test.expected, test.stage, test.from)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/jjmrocha/beast/config"
+)
+
+// wait calculates the time a concurrent request waits before executing the next one,
+// each goroutine must have its own wait because rand.Rand is not safe for concurrent use
+type wait struct {
+	thinkTime config.ThinkTime
+	pacing    time.Duration
+	random    *rand.Rand
+}
+
+func newWait(thinkTime config.ThinkTime, pacing time.Duration) *wait {
+	return &wait{
+		thinkTime: thinkTime,
+		pacing:    pacing,
+		random:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// duration returns the time to wait after a request that started at iterationStart,
+// with pacing every iteration starts on a fixed period, otherwise the think time is used
+func (w *wait) duration(iterationStart time.Time) time.Duration {
+	if w.pacing > 0 {
+		return time.Until(iterationStart.Add(w.pacing))
+	}
+
+	return w.think()
+}
+
+func (w *wait) think() time.Duration {
+	var value float64
+	tt := w.thinkTime
+
+	switch tt.Distribution {
+	case config.FixedThinkTime:
+		value = float64(tt.Mean)
+	case config.UniformThinkTime:
+		value = float64(tt.Min)
+		if tt.Max > tt.Min {
+			value += float64(w.random.Int63n(int64(tt.Max - tt.Min + 1)))
+		}
+	case config.NormalThinkTime:
+		value = w.random.NormFloat64()*float64(tt.StdDev) + float64(tt.Mean)
+	case config.ExponentialThinkTime:
+		value = w.random.ExpFloat64() * float64(tt.Mean)
+	default:
+		return 0
+	}
+
+	if value < float64(tt.Min) {
+		value = float64(tt.Min)
+	}
+
+	if tt.Max > 0 && value > float64(tt.Max) {
+		value = float64(tt.Max)
+	}
+
+	return time.Duration(value * float64(time.Millisecond))
+}
+
+// until returns the time when the next request can be sent
+func (w *wait) until(iterationStart time.Time) time.Time {
+	return time.Now().Add(w.duration(iterationStart))
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jjmrocha/beast/config"
+)
+
+func TestThinkTimeFixed(t *testing.T) {
+	// given
+	underTest := newWait(config.ThinkTime{Distribution: config.FixedThinkTime, Mean: 150}, 0)
+	expected := 150 * time.Millisecond
+	// when
+	result := underTest.duration(time.Now())
+	// then
+	if result != expected {
+		t.Errorf("got %v expected %v", result, expected)
+	}
+}
+
+func TestThinkTimeNone(t *testing.T) {
+	// given
+	underTest := newWait(config.ThinkTime{Distribution: config.NoThinkTime, Mean: 150}, 0)
+	// when
+	result := underTest.duration(time.Now())
+	// then
+	if result != 0 {
+		t.Errorf("got %v expected %v", result, 0)
+	}
+}
+
+func TestThinkTimeLimits(t *testing.T) {
+	// given
+	var tests = []config.ThinkTime{
+		{Distribution: config.UniformThinkTime, Min: 10, Max: 20},
+		{Distribution: config.NormalThinkTime, Min: 10, Max: 20, Mean: 15, StdDev: 50},
+		{Distribution: config.ExponentialThinkTime, Min: 10, Max: 20, Mean: 15},
+	}
+	// then
+	for _, test := range tests {
+		underTest := newWait(test, 0)
+
+		for i := 0; i < 1000; i++ {
+			result := underTest.duration(time.Now())
+			if result < 10*time.Millisecond || result > 20*time.Millisecond {
+				t.Errorf("got %v expected value between 10ms and 20ms for %v", result, test.Distribution)
+				break
+			}
+		}
+	}
+}
+
+func TestPacing(t *testing.T) {
+	// given
+	underTest := newWait(config.ThinkTime{Distribution: config.FixedThinkTime, Mean: 500}, time.Second)
+	iterationStart := time.Now().Add(-300 * time.Millisecond)
+	// when
+	result := underTest.duration(iterationStart)
+	// then
+	if result > 700*time.Millisecond || result < 600*time.Millisecond {
+		t.Errorf("got %v expected about %v", result, 700*time.Millisecond)
+	}
+}
@@ -19,8 +19,8 @@ package data
 
 import (
 	"encoding/csv"
+	"fmt"
 	"io"
-	"log"
 	"os"
 )
 
@@ -57,11 +57,67 @@ func (d *Data) Next() *Record {
 	return &next
 }
 
+// Rows returns the content of the data, the first row has the field names
+func (d *Data) Rows() [][]string {
+	rows := make([][]string, 0, len(d.records)+1)
+	rows = append(rows, d.fields)
+
+	for _, record := range d.records {
+		row := make([]string, 0, len(d.fields))
+
+		for _, field := range d.fields {
+			row = append(row, record[field])
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// FromRows creates a Data using the rows, the first row must contain the field names
+func FromRows(rows [][]string) *Data {
+	dt := Data{
+		records: make([]Record, 0),
+	}
+
+	for _, row := range rows {
+		dt.add(row)
+	}
+
+	return &dt
+}
+
+// Split divides the records into n partitions of similar size,
+// if there are less records than partitions, all partitions receive all the records
+func (d *Data) Split(n int) []*Data {
+	partitions := make([]*Data, 0, n)
+
+	for i := 0; i < n; i++ {
+		partition := &Data{
+			fields:  d.fields,
+			records: make([]Record, 0),
+		}
+
+		if len(d.records) < n {
+			partition.records = append(partition.records, d.records...)
+		} else {
+			start := i * len(d.records) / n
+			end := (i + 1) * len(d.records) / n
+			partition.records = append(partition.records, d.records[start:end]...)
+		}
+
+		partitions = append(partitions, partition)
+	}
+
+	return partitions
+}
+
 // Read reads the content of the CSV file
-func Read(fileName string) *Data {
+func Read(fileName string) (*Data, error) {
 	csvfile, err := os.Open(fileName)
 	if err != nil {
-		log.Fatalf("Error reading data file %s: %v\n", fileName, err)
+		return nil, fmt.Errorf("error reading data file %s: %v", fileName, err)
 	}
 
 	defer csvfile.Close()
@@ -75,11 +131,11 @@ func Read(fileName string) *Data {
 		if err == io.EOF {
 			break
 		} else if err != nil {
-			log.Fatalf("Error reading data file %s: %v\n", fileName, err)
+			return nil, fmt.Errorf("error reading data file %s: %v", fileName, err)
 		}
 
 		dt.add(record)
 	}
 
-	return &dt
+	return &dt, nil
 }
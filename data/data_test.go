@@ -31,8 +31,12 @@ func TestRead(t *testing.T) {
 		},
 	}
 	// when
-	result := Read("../testdata/data.csv")
+	result, err := Read("../testdata/data.csv")
 	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
 	if !reflect.DeepEqual(result, expectedData) {
 		t.Errorf("got %v expected %v", result, expectedData)
 	}
@@ -69,3 +73,58 @@ func TestNext(t *testing.T) {
 		t.Errorf("got %v expected %v", thirdRecord, &expectedFirstRecord)
 	}
 }
+
+func TestRowsConvertion(t *testing.T) {
+	// given
+	dt, _ := Read("../testdata/data.csv")
+	expectedRows := [][]string{
+		{"A", "B"},
+		{"a1", "b1"},
+		{"a2", "b2"},
+	}
+	// when
+	rows := dt.Rows()
+	result := FromRows(rows)
+	// then
+	if !reflect.DeepEqual(rows, expectedRows) {
+		t.Errorf("got %v expected %v", rows, expectedRows)
+	}
+
+	if !reflect.DeepEqual(result, dt) {
+		t.Errorf("got %v expected %v", result, dt)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	// given
+	dt := &Data{
+		fields: []string{"A"},
+		records: []Record{
+			{"A": "a1"},
+			{"A": "a2"},
+			{"A": "a3"},
+		},
+	}
+	var tests = []struct {
+		partitions int
+		expected   []int
+	}{
+		{1, []int{3}},
+		{2, []int{1, 2}},
+		{3, []int{1, 1, 1}},
+		{4, []int{3, 3, 3, 3}},
+	}
+	// then
+	for _, test := range tests {
+		result := dt.Split(test.partitions)
+		sizes := make([]int, 0, len(result))
+
+		for _, partition := range result {
+			sizes = append(sizes, len(partition.records))
+		}
+
+		if !reflect.DeepEqual(sizes, test.expected) {
+			t.Errorf("got %v expected %v for %v partitions", sizes, test.expected, test.partitions)
+		}
+	}
+}
@@ -3,3 +3,8 @@ module github.com/jjmrocha/beast
 go 1.17
 
 require gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
+
+require (
+	golang.org/x/net v0.7.0
+	golang.org/x/text v0.7.0 // indirect
+)
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loadtest
+
+import (
+	"context"
+	"time"
+
+	"github.com/jjmrocha/beast/client"
+	"github.com/jjmrocha/beast/report"
+)
+
+// Execution is a test in execution, local (control.Control) or distributed (remote.Cluster)
+type Execution interface {
+	OutputChannel() <-chan *client.Response
+	Stop()
+	Cancel()
+}
+
+// Collector updates the stats with the responses of a test until the test ends,
+// used by Run and by the beast command
+type Collector struct {
+	Stats   *report.Stats
+	Monitor *report.Monitor
+	// Time the requests in flight have to complete when the test is stopped before the end,
+	// zero cancels them immediately
+	GracePeriod time.Duration
+	// Reason of the stop when the context is done, "cancelled, <context error>" when empty
+	CancelReason string
+	// Optional function called with every response
+	OnResponse func(*client.Response)
+	// Optional function called when the test is stopped before the end, with the reason
+	OnStop func(reason string)
+	// Optional function called when the requests in flight are cancelled at the end of the grace period
+	OnCancel func()
+}
+
+// Collect waits for the end of the test and returns the outcome, when ctx is done or the monitor
+// aborts the test, new requests are stopped and the requests in flight cancelled after the grace period
+func (c *Collector) Collect(ctx context.Context, exec Execution) Outcome {
+	var grace <-chan time.Time
+	outcome := Completed
+	done := ctx.Done()
+	output := exec.OutputChannel()
+
+	stop := func(reason string, result Outcome) {
+		c.Stats.Stopped(reason)
+		outcome = result
+
+		if c.OnStop != nil {
+			c.OnStop(reason)
+		}
+
+		if c.GracePeriod == 0 {
+			exec.Cancel()
+			return
+		}
+
+		exec.Stop()
+		grace = time.After(c.GracePeriod)
+	}
+
+	for {
+		select {
+		case response, ok := <-output:
+			if !ok {
+				return outcome
+			}
+
+			c.Stats.Update(response)
+
+			if c.OnResponse != nil {
+				c.OnResponse(response)
+			}
+
+			if reason, abort := c.Monitor.Check(response); abort && outcome == Completed {
+				stop("aborted, "+reason, Aborted)
+			}
+		case <-done:
+			done = nil
+
+			if outcome == Completed {
+				reason := c.CancelReason
+				if reason == "" {
+					reason = "cancelled, " + ctx.Err().Error()
+				}
+
+				stop(reason, Cancelled)
+			}
+		case <-grace:
+			grace = nil
+
+			if c.OnCancel != nil {
+				c.OnCancel()
+			}
+
+			exec.Cancel()
+		}
+	}
+}
@@ -0,0 +1,198 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package loadtest provides an API to execute load tests from Go code,
+// errors are returned instead of terminating the process
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jjmrocha/beast/client"
+	"github.com/jjmrocha/beast/config"
+	"github.com/jjmrocha/beast/control"
+	"github.com/jjmrocha/beast/data"
+	"github.com/jjmrocha/beast/report"
+	"github.com/jjmrocha/beast/template"
+)
+
+// Outcome describes how a test ended
+type Outcome int
+
+// Possible outcomes of a test
+const (
+	// All requests of the plan were executed
+	Completed Outcome = iota
+	// The test was stopped by the abort criteria
+	Aborted
+	// The test was stopped because the context was done
+	Cancelled
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Completed:
+		return "completed"
+	case Aborted:
+		return "aborted"
+	case Cancelled:
+		return "cancelled"
+	}
+
+	return fmt.Sprintf("Outcome(%d)", int(o))
+}
+
+// Plan describes a load test
+type Plan struct {
+	// Request sent by the test, can use the same template variables of template files
+	Template *template.Template
+	// Requests executed in sequence, used instead of Template
+	Scenario *template.Scenario
+	// Templates or scenarios chosen by weight for each request, used instead of Template
+	Mix *template.Mix
+	// HTTP client configuration, including think time, pacing and sessions, config.Default() when nil
+	Config *config.Config
+	// Optional data used to generate the requests
+	Data *data.Data
+	// Load generated, when Requests and Duration are zero the test runs until the context is done
+	Load control.Load
+	// Initial part of the test excluded from the results
+	Warmup time.Duration
+	// Optional criteria to stop the test before the end
+	Abort report.AbortCriteria
+	// Optional CSV file with the results of every request
+	OutputFile string
+	// Time the requests in flight have to complete when the test is stopped before the end,
+	// zero cancels them immediately
+	GracePeriod time.Duration
+	// Optional function called with every response while the test is executing
+	OnResponse func(*client.Response)
+}
+
+// Result contains the results of a test
+type Result struct {
+	report.Summary
+	Outcome Outcome
+}
+
+type noProgress struct{}
+
+func (noProgress) Update() {}
+
+// Run executes the test described by plan, waiting for the end of the test,
+// when ctx is done the test is stopped and the results of the executed requests returned
+func Run(ctx context.Context, plan Plan) (Result, error) {
+	cfg := plan.Config
+	if cfg == nil {
+		cfg = config.Default()
+	}
+
+	if err := cfg.Check(); err != nil {
+		return Result{}, err
+	}
+
+	load := plan.Load
+	load.ThinkTime = cfg.ThinkTime
+	load.Pacing = time.Duration(cfg.Pacing) * time.Millisecond
+	load.Sessions = cfg.Sessions
+
+	if err := plan.check(load); err != nil {
+		return Result{}, err
+	}
+
+	mix := plan.Mix
+	if plan.Scenario != nil {
+		mix = template.NewMix(plan.Scenario)
+	} else if plan.Template != nil {
+		mix = template.NewMix(template.NewScenario(plan.Template))
+	}
+
+	tmplc, err := mix.Compile()
+	if err != nil {
+		return Result{}, fmt.Errorf("error compiling template: %v", err)
+	}
+
+	httpClient, err := client.NewClient(cfg, load.MaxConcurrency())
+	if err != nil {
+		return Result{}, err
+	}
+
+	stats, err := report.NewStats(noProgress{}, plan.OutputFile, load.Stages, plan.Warmup)
+	if err != nil {
+		return Result{}, err
+	}
+
+	ctrl := control.New(load)
+	ctrl.AsyncExecute(httpClient, tmplc, plan.Data)
+
+	collector := &Collector{
+		Stats:       stats,
+		Monitor:     report.NewMonitor(plan.Abort),
+		GracePeriod: plan.GracePeriod,
+		OnResponse:  plan.OnResponse,
+	}
+	outcome := collector.Collect(ctx, ctrl)
+	stats.Close()
+
+	return Result{
+		Summary: stats.Summary(),
+		Outcome: outcome,
+	}, nil
+}
+
+func (p *Plan) check(load control.Load) error {
+	sources := 0
+	for _, present := range []bool{p.Template != nil, p.Scenario != nil, p.Mix != nil} {
+		if present {
+			sources++
+		}
+	}
+
+	if sources != 1 {
+		return errors.New("one of template, scenario or mix is required")
+	}
+
+	if load.Requests < 0 || load.Duration < 0 || load.Rate < 0 || load.Iterations < 0 || p.Warmup < 0 || p.GracePeriod < 0 {
+		return errors.New("load values must be zero or positive")
+	}
+
+	if load.Iterations > 0 {
+		if load.Requests > 0 || load.Duration > 0 || load.Rate > 0 || len(load.Stages) > 0 {
+			return errors.New("iterations can't be used with requests, duration, rate or stages")
+		}
+	}
+
+	if len(load.Stages) > 0 {
+		if load.Requests > 0 || load.Duration > 0 || load.Rate > 0 {
+			return errors.New("stages can't be used with requests, duration or rate")
+		}
+	} else if load.Concurrency <= 0 {
+		return errors.New("concurrency must be positive")
+	}
+
+	if duration := time.Duration(load.TestDuration()) * time.Second; duration > 0 && p.Warmup >= duration {
+		return errors.New("warm-up must be shorter than the test")
+	}
+
+	if (p.Abort.ErrorRate > 0 || p.Abort.Latency > 0) && p.Abort.Window <= 0 {
+		return errors.New("abort window must be positive")
+	}
+
+	return nil
+}
@@ -0,0 +1,319 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jjmrocha/beast/client"
+	"github.com/jjmrocha/beast/config"
+	"github.com/jjmrocha/beast/control"
+	"github.com/jjmrocha/beast/report"
+	"github.com/jjmrocha/beast/template"
+)
+
+func startServer(status int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+}
+
+func TestRun(t *testing.T) {
+	// given
+	server := startServer(http.StatusOK)
+	defer server.Close()
+
+	var received int32
+	plan := Plan{
+		Template: &template.Template{Method: "GET", Endpoint: server.URL + "/{{ .RequestID }}"},
+		Load:     control.Load{Requests: 20, Concurrency: 2},
+		OnResponse: func(response *client.Response) {
+			atomic.AddInt32(&received, 1)
+		},
+	}
+	// when
+	result, err := Run(context.Background(), plan)
+	// then
+	if err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	if result.Outcome != Completed {
+		t.Errorf("got %v expected %v", result.Outcome, Completed)
+	}
+
+	if result.Requests != 20 || result.Success[http.StatusOK].Requests != 20 {
+		t.Errorf("got %v requests and %v successful expected 20", result.Requests, result.Success[http.StatusOK].Requests)
+	}
+
+	if received != 20 {
+		t.Errorf("got %v responses on callback expected 20", received)
+	}
+}
+
+func TestRunScenario(t *testing.T) {
+	// given
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1"})
+			w.Write([]byte(`{"user": {"id": 7}}`))
+		case "/users/7":
+			if cookie, err := r.Cookie("session"); err != nil || cookie.Value != r.URL.Query().Get("session") {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	plan := Plan{
+		Scenario: &template.Scenario{
+			Steps: []template.Step{
+				{
+					Request: &template.Template{Method: "POST", Endpoint: server.URL + "/login"},
+					Extract: []template.Extract{
+						{Variable: "id", From: template.FromJSON, Expression: "user.id"},
+						{Variable: "session", From: template.FromCookie, Expression: "session"},
+					},
+				},
+				{
+					Request: &template.Template{
+						Method:   "GET",
+						Endpoint: server.URL + "/users/{{ .Vars.id }}?session={{ .Vars.session }}",
+						Headers:  []template.Header{{Key: "Cookie", Value: "session={{ .Vars.session }}"}},
+					},
+				},
+			},
+		},
+		Load: control.Load{Requests: 5, Concurrency: 2},
+	}
+	// when
+	result, err := Run(context.Background(), plan)
+	// then
+	if err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	if result.Requests != 10 || result.Success[http.StatusOK].Requests != 10 {
+		t.Errorf("got %v requests and %v successful expected 10", result.Requests, result.Success[http.StatusOK].Requests)
+	}
+}
+
+func TestRunSessions(t *testing.T) {
+	// given
+	var mutex sync.Mutex
+	visits := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		cookie, err := r.Cookie("user")
+		if err != nil {
+			cookie = &http.Cookie{Name: "user", Value: strconv.Itoa(len(visits))}
+			http.SetCookie(w, cookie)
+		} else if r.URL.Query().Get("visits") != strconv.Itoa(visits[cookie.Value]) {
+			w.WriteHeader(http.StatusConflict)
+		}
+
+		visits[cookie.Value]++
+		w.Header().Set("Visits", strconv.Itoa(visits[cookie.Value]))
+	}))
+	defer server.Close()
+
+	tmpl := &template.Template{Method: "GET", Endpoint: server.URL + "/?visits={{ index .Vars \"visits\" }}"}
+	scenario := template.NewScenario(tmpl)
+	scenario.Steps[0].Extract = []template.Extract{{Variable: "visits", From: template.FromHeader, Expression: "Visits"}}
+	cfg := config.Default()
+	cfg.Sessions = true
+	plan := Plan{
+		Scenario: scenario,
+		Config:   cfg,
+		Load:     control.Load{Requests: 10, Concurrency: 2},
+	}
+	// when
+	result, err := Run(context.Background(), plan)
+	// then
+	if err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	if result.Success[http.StatusOK].Requests != 10 {
+		t.Errorf("got %v successful requests expected 10", result.Success[http.StatusOK].Requests)
+	}
+
+	if len(visits) != 2 {
+		t.Errorf("got %v users expected 2", len(visits))
+	}
+}
+
+func TestRunIterations(t *testing.T) {
+	// given
+	var mutex sync.Mutex
+	paths := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		paths[r.URL.Path]++
+	}))
+	defer server.Close()
+
+	plan := Plan{
+		Template: &template.Template{Method: "GET", Endpoint: server.URL + "/{{ .UserID }}/{{ .Iteration }}"},
+		Load:     control.Load{Concurrency: 3, Iterations: 4},
+	}
+	// when
+	result, err := Run(context.Background(), plan)
+	// then
+	if err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	if result.Requests != 12 {
+		t.Errorf("got %v requests expected 12", result.Requests)
+	}
+
+	for user := 1; user <= 3; user++ {
+		for iteration := 1; iteration <= 4; iteration++ {
+			if path := fmt.Sprintf("/%v/%v", user, iteration); paths[path] != 1 {
+				t.Errorf("got %v requests to %v expected 1", paths[path], path)
+			}
+		}
+	}
+}
+
+func TestRunWithChecks(t *testing.T) {
+	// given
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1)%2 == 0 {
+			w.Write([]byte(`{"status": "error"}`))
+			return
+		}
+
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer server.Close()
+
+	plan := Plan{
+		Template: &template.Template{
+			Method:   "GET",
+			Endpoint: server.URL,
+			Checks:   &template.Checks{JSON: map[string]string{"status": "ok"}},
+		},
+		Load: control.Load{Requests: 10, Concurrency: 1},
+	}
+	// when
+	result, err := Run(context.Background(), plan)
+	// then
+	if err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	if result.Success[http.StatusOK].Requests != 5 || result.FailedChecks["json status"] != 5 {
+		t.Errorf("got %v successful and %v failed checks expected 5 and 5", result.Success[http.StatusOK].Requests, result.FailedChecks)
+	}
+
+	if result.ErrorRate != 50 {
+		t.Errorf("got %v expected 50 for error rate", result.ErrorRate)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	// given
+	server := startServer(http.StatusOK)
+	defer server.Close()
+
+	plan := Plan{
+		Template: &template.Template{Method: "GET", Endpoint: server.URL},
+		Load:     control.Load{Concurrency: 1, Rate: 100},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	// when
+	result, err := Run(ctx, plan)
+	// then
+	if err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	if result.Outcome != Cancelled || result.StopReason == "" {
+		t.Errorf("got %v (%v) expected %v", result.Outcome, result.StopReason, Cancelled)
+	}
+
+	if result.Requests == 0 {
+		t.Error("expected requests to be executed")
+	}
+}
+
+func TestRunAborted(t *testing.T) {
+	// given
+	server := startServer(http.StatusInternalServerError)
+	defer server.Close()
+
+	plan := Plan{
+		Template: &template.Template{Method: "GET", Endpoint: server.URL},
+		Load:     control.Load{Duration: 10, Concurrency: 1},
+		Abort:    report.AbortCriteria{ErrorRate: 50, Window: 5},
+	}
+	// when
+	result, err := Run(context.Background(), plan)
+	// then
+	if err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	if result.Outcome != Aborted {
+		t.Errorf("got %v expected %v", result.Outcome, Aborted)
+	}
+
+	if result.NonSuccess[http.StatusInternalServerError] == 0 || result.ErrorRate != 100 {
+		t.Errorf("got %v with error rate %v", result.NonSuccess, result.ErrorRate)
+	}
+}
+
+func TestRunInvalidPlan(t *testing.T) {
+	// given
+	tmpl := &template.Template{Method: "GET", Endpoint: "http://localhost"}
+	var tests = []Plan{
+		{Load: control.Load{Requests: 1, Concurrency: 1}},
+		{Template: tmpl, Scenario: template.NewScenario(tmpl), Load: control.Load{Requests: 1, Concurrency: 1}},
+		{Template: tmpl, Load: control.Load{Requests: 1}},
+		{Template: tmpl, Load: control.Load{Requests: -1, Concurrency: 1}},
+		{Template: tmpl, Load: control.Load{Requests: 1, Concurrency: 1, Iterations: 1}},
+		{Template: tmpl, Load: control.Load{Requests: 1, Stages: []control.Stage{{Duration: time.Second, Target: 1}}}},
+		{Template: tmpl, Load: control.Load{Duration: 1, Concurrency: 1}, Warmup: time.Second},
+		{Template: tmpl, Load: control.Load{Requests: 1, Concurrency: 1}, Abort: report.AbortCriteria{ErrorRate: 10}},
+		{Template: &template.Template{Method: "GET", Endpoint: "{{ .Missing"}, Load: control.Load{Requests: 1, Concurrency: 1}},
+	}
+	// then
+	for i, plan := range tests {
+		_, err := Run(context.Background(), plan)
+		if err == nil {
+			t.Errorf("expected error for plan %v", i)
+		}
+	}
+}
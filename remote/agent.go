@@ -0,0 +1,147 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/jjmrocha/beast/client"
+	"github.com/jjmrocha/beast/control"
+	"github.com/jjmrocha/beast/data"
+)
+
+// Serve accepts connections from coordinators and executes their jobs, one job at a time
+func Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		serveJob(newConnection(conn))
+	}
+}
+
+func serveJob(conn *connection) {
+	defer conn.close()
+
+	msg, err := conn.receive()
+	if err != nil || msg.Kind != jobMessage || msg.Job == nil {
+		log.Printf("Invalid job received from %v: %v\n", conn, err)
+		return
+	}
+
+	log.Printf("Job received from %v\n", conn)
+	job := msg.Job
+	ctrl, start, err := prepare(job)
+	if err != nil {
+		log.Printf("Error preparing job: %v\n", err)
+		conn.send(&message{Kind: errorMessage, Error: err.Error()})
+		return
+	}
+
+	if err := conn.send(&message{Kind: readyMessage}); err != nil {
+		log.Printf("Error sending message to %v: %v\n", conn, err)
+		return
+	}
+
+	msg, err = conn.receive()
+	if err != nil || msg.Kind != startMessage {
+		log.Printf("Job cancelled by %v: %v\n", conn, err)
+		return
+	}
+
+	log.Printf("Job started\n")
+	start()
+	go listenCoordinator(conn, ctrl)
+
+	// The warm-up is measured with the clock of the agent, like the timestamps of the responses
+	warmupEnd := time.Now().Add(job.Warmup)
+
+	count := 0
+	for response := range ctrl.OutputChannel() {
+		if job.Warmup > 0 && response.Timestamp.Before(warmupEnd) {
+			response.Warmup = true
+		}
+
+		if err := conn.send(&message{Kind: responseMessage, Response: response}); err != nil {
+			log.Printf("Lost connection to %v: %v\n", conn, err)
+			ctrl.Cancel()
+			continue
+		}
+
+		count++
+	}
+
+	conn.send(&message{Kind: doneMessage})
+	log.Printf("Job completed with %v requests\n", count)
+}
+
+// prepare creates the control for the job, returning also the function that starts the execution
+func prepare(job *Job) (*control.Control, func(), error) {
+	if job.Mix == nil || job.Config == nil {
+		return nil, nil, fmt.Errorf("incomplete job")
+	}
+
+	if err := job.Config.Check(); err != nil {
+		return nil, nil, err
+	}
+
+	tmplc, err := job.Mix.Compile()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error compiling templates: %w", err)
+	}
+
+	var rows *data.Data
+	if job.Rows != nil {
+		rows = data.FromRows(job.Rows)
+	}
+
+	httpClient, err := client.NewClient(job.Config, job.Load.MaxConcurrency())
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating HTTP client: %w", err)
+	}
+
+	ctrl := control.New(job.Load)
+	start := func() {
+		ctrl.AsyncExecute(httpClient, tmplc, rows)
+	}
+
+	return ctrl, start, nil
+}
+
+// listenCoordinator waits for stop requests from the coordinator,
+// losing the connection to the coordinator cancels the test
+func listenCoordinator(conn *connection, ctrl *control.Control) {
+	for {
+		msg, err := conn.receive()
+		if err != nil {
+			ctrl.Cancel()
+			return
+		}
+
+		switch msg.Kind {
+		case stopMessage:
+			ctrl.Stop()
+		case cancelMessage:
+			ctrl.Cancel()
+		}
+	}
+}
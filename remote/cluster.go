@@ -0,0 +1,186 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/jjmrocha/beast/client"
+	"github.com/jjmrocha/beast/config"
+	"github.com/jjmrocha/beast/control"
+	"github.com/jjmrocha/beast/data"
+	"github.com/jjmrocha/beast/template"
+)
+
+const dialTimeout = 10 * time.Second
+
+// Cluster is the coordinator of the agents executing a test
+type Cluster struct {
+	agents        []*connection
+	outputChannel chan *client.Response
+}
+
+// Connect opens a connection to each one of the agents
+func Connect(addresses []string) (*Cluster, error) {
+	agents := make([]*connection, 0, len(addresses))
+
+	for _, address := range addresses {
+		conn, err := net.DialTimeout("tcp", address, dialTimeout)
+		if err != nil {
+			for _, agent := range agents {
+				agent.close()
+			}
+
+			return nil, fmt.Errorf("error connecting to agent %v: %w", address, err)
+		}
+
+		agents = append(agents, newConnection(conn))
+	}
+
+	return &Cluster{
+		agents:        agents,
+		outputChannel: make(chan *client.Response, 1024),
+	}, nil
+}
+
+// Execute sends to each agent its part of the test and starts all agents at the same time,
+// a template is sent as a mix with a single scenario of a single step
+func (c *Cluster) Execute(mix *template.Mix, cfg *config.Config, rows *data.Data, load control.Load, warmup time.Duration) error {
+	if err := c.Prepare(mix, cfg, rows, load, warmup); err != nil {
+		return err
+	}
+
+	return c.Start()
+}
+
+// Prepare sends to each agent its part of the test and waits until all agents are ready to start,
+// the responses received during the warmup are marked by the agents, as their clocks may differ
+func (c *Cluster) Prepare(mix *template.Mix, cfg *config.Config, rows *data.Data, load control.Load, warmup time.Duration) error {
+	size := len(c.agents)
+	loads := load.Split(size)
+
+	var partitions []*data.Data
+	if rows != nil {
+		partitions = rows.Split(size)
+	}
+
+	for i, agent := range c.agents {
+		job := &Job{
+			Mix:    mix,
+			Config: cfg,
+			Load:   loads[i],
+			Warmup: warmup,
+		}
+
+		if partitions != nil {
+			job.Rows = partitions[i].Rows()
+		}
+
+		if err := agent.send(&message{Kind: jobMessage, Job: job}); err != nil {
+			return c.abort(fmt.Errorf("error sending job to agent %v: %w", agent, err))
+		}
+	}
+
+	for _, agent := range c.agents {
+		msg, err := agent.receive()
+		if err != nil {
+			return c.abort(fmt.Errorf("error receiving reply from agent %v: %w", agent, err))
+		}
+
+		if msg.Kind != readyMessage {
+			return c.abort(fmt.Errorf("agent %v failed to prepare the job: %v", agent, msg.Error))
+		}
+	}
+
+	return nil
+}
+
+// Start starts all agents prepared by Prepare at the same time
+func (c *Cluster) Start() error {
+	for _, agent := range c.agents {
+		if err := agent.send(&message{Kind: startMessage}); err != nil {
+			return c.abort(fmt.Errorf("error starting agent %v: %w", agent, err))
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(c.agents))
+
+	for _, agent := range c.agents {
+		go c.receiveResponses(agent, &wg)
+	}
+
+	go func() {
+		wg.Wait()
+		close(c.outputChannel)
+	}()
+
+	return nil
+}
+
+func (c *Cluster) abort(err error) error {
+	for _, agent := range c.agents {
+		agent.close()
+	}
+
+	return err
+}
+
+func (c *Cluster) receiveResponses(agent *connection, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer agent.close()
+
+	for {
+		msg, err := agent.receive()
+		if err != nil {
+			log.Printf("Lost connection to agent %v: %v\n", agent, err)
+			return
+		}
+
+		switch msg.Kind {
+		case responseMessage:
+			c.outputChannel <- msg.Response
+		case doneMessage:
+			return
+		}
+	}
+}
+
+// OutputChannel returns a channel with the responses received from all agents
+func (c *Cluster) OutputChannel() <-chan *client.Response {
+	return c.outputChannel
+}
+
+// Stop requests all agents to stop the generation of new requests
+func (c *Cluster) Stop() {
+	c.broadcast(stopMessage)
+}
+
+// Cancel requests all agents to stop the test and cancel the requests in flight
+func (c *Cluster) Cancel() {
+	c.broadcast(cancelMessage)
+}
+
+func (c *Cluster) broadcast(kind string) {
+	for _, agent := range c.agents {
+		agent.send(&message{Kind: kind})
+	}
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package remote provides the agent and coordinator used to distribute the load generation by several machines
+package remote
+
+import (
+	"encoding/gob"
+	"net"
+	"time"
+
+	"github.com/jjmrocha/beast/client"
+	"github.com/jjmrocha/beast/config"
+	"github.com/jjmrocha/beast/control"
+	"github.com/jjmrocha/beast/template"
+)
+
+// Job contains everything an agent needs to execute its part of the test
+type Job struct {
+	Mix    *template.Mix
+	Config *config.Config
+	// Data partition of the agent, the first row has the field names, nil when not used
+	Rows [][]string
+	Load control.Load
+	// Initial part of the test, the agent marks the responses as warm-up using its own clock
+	Warmup time.Duration
+}
+
+// Message kinds
+const (
+	// Coordinator to agent
+	jobMessage    = "job"
+	startMessage  = "start"
+	stopMessage   = "stop"
+	cancelMessage = "cancel"
+	// Agent to coordinator
+	readyMessage    = "ready"
+	responseMessage = "response"
+	doneMessage     = "done"
+	errorMessage    = "error"
+)
+
+// message is the envelope of everything sent between coordinator and agents
+type message struct {
+	Kind     string
+	Job      *Job
+	Response *client.Response
+	Error    string
+}
+
+// connection sends and receives messages using gob over TCP
+type connection struct {
+	conn    net.Conn
+	encoder *gob.Encoder
+	decoder *gob.Decoder
+}
+
+func newConnection(conn net.Conn) *connection {
+	return &connection{
+		conn:    conn,
+		encoder: gob.NewEncoder(conn),
+		decoder: gob.NewDecoder(conn),
+	}
+}
+
+func (c *connection) send(msg *message) error {
+	return c.encoder.Encode(msg)
+}
+
+func (c *connection) receive() (*message, error) {
+	var msg message
+	if err := c.decoder.Decode(&msg); err != nil {
+		return nil, err
+	}
+
+	return &msg, nil
+}
+
+func (c *connection) String() string {
+	return c.conn.RemoteAddr().String()
+}
+
+func (c *connection) close() {
+	c.conn.Close()
+}
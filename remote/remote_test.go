@@ -0,0 +1,199 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jjmrocha/beast/config"
+	"github.com/jjmrocha/beast/control"
+	"github.com/jjmrocha/beast/data"
+	"github.com/jjmrocha/beast/template"
+)
+
+func startAgent(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error starting agent: %v", err)
+	}
+
+	go Serve(listener)
+	return listener.Addr().String()
+}
+
+func TestClusterExecute(t *testing.T) {
+	// given
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	agents := []string{startAgent(t), startAgent(t)}
+	tmpl := &template.Template{
+		Method:   "GET",
+		Endpoint: server.URL + "/{{ .Data.A }}",
+	}
+	rows, _ := data.Read("../testdata/data.csv")
+	load := control.Load{Requests: 9, Concurrency: 2}
+	// when
+	cluster, err := Connect(agents)
+	if err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	if err := cluster.Execute(template.NewMix(template.NewScenario(tmpl)), config.Default(), rows, load, 0); err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	count := 0
+	for response := range cluster.OutputChannel() {
+		if response.StatusCode != http.StatusNoContent {
+			t.Errorf("got %v expected %v", response.StatusCode, http.StatusNoContent)
+		}
+
+		count++
+	}
+	// then
+	if count != load.Requests {
+		t.Errorf("got %v expected %v requests", count, load.Requests)
+	}
+}
+
+func TestClusterUniqueIDs(t *testing.T) {
+	// given
+	var mutex sync.Mutex
+	paths := make(map[string]bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		paths[r.URL.Path] = true
+	}))
+	defer server.Close()
+
+	agents := []string{startAgent(t), startAgent(t)}
+	tmpl := &template.Template{
+		Method:   "GET",
+		Endpoint: server.URL + "/{{ .UserID }}/{{ .Iteration }}/{{ .RequestID }}",
+	}
+	load := control.Load{Concurrency: 4, Iterations: 2}
+	// when
+	cluster, err := Connect(agents)
+	if err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	if err := cluster.Execute(template.NewMix(template.NewScenario(tmpl)), config.Default(), nil, load, 0); err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	for range cluster.OutputChannel() {
+	}
+	// then
+	users := make(map[string]bool)
+	requests := make(map[string]bool)
+	for path := range paths {
+		parts := strings.Split(path, "/")
+		users[parts[1]+"/"+parts[2]] = true
+		requests[parts[3]] = true
+	}
+
+	if len(users) != load.TotalRequests() {
+		t.Errorf("got %v expected %v distinct user iterations", users, load.TotalRequests())
+	}
+
+	if len(requests) != load.TotalRequests() {
+		t.Errorf("got %v expected %v distinct request IDs", requests, load.TotalRequests())
+	}
+}
+
+func TestClusterWarmup(t *testing.T) {
+	// given
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	agents := []string{startAgent(t)}
+	tmpl := &template.Template{
+		Method:   "GET",
+		Endpoint: server.URL,
+	}
+	load := control.Load{Requests: 3, Concurrency: 1}
+	// when
+	cluster, err := Connect(agents)
+	if err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	if err := cluster.Execute(template.NewMix(template.NewScenario(tmpl)), config.Default(), nil, load, time.Hour); err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+	// then
+	for response := range cluster.OutputChannel() {
+		if !response.Warmup {
+			t.Errorf("got %+v expected a warm-up response", response)
+		}
+	}
+}
+
+func TestClusterInvalidTemplate(t *testing.T) {
+	// given
+	agents := []string{startAgent(t)}
+	tmpl := &template.Template{
+		Method:   "GET",
+		Endpoint: "http://localhost/{{ .Data.A ",
+	}
+	load := control.Load{Requests: 1, Concurrency: 1}
+	// when
+	cluster, err := Connect(agents)
+	if err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	err = cluster.Execute(template.NewMix(template.NewScenario(tmpl)), config.Default(), nil, load, 0)
+	// then
+	if err == nil {
+		t.Errorf("Error expected for invalid template")
+	}
+}
+
+func TestClusterInvalidConfig(t *testing.T) {
+	// given
+	agents := []string{startAgent(t)}
+	tmpl := &template.Template{
+		Method:   "GET",
+		Endpoint: "http://localhost/",
+	}
+	cfg := config.Default()
+	cfg.MaxConnections = -1
+	load := control.Load{Requests: 1, Concurrency: 1}
+	// when
+	cluster, err := Connect(agents)
+	if err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	err = cluster.Execute(template.NewMix(template.NewScenario(tmpl)), cfg, nil, load, 0)
+	// then
+	if err == nil {
+		t.Errorf("Error expected for invalid config")
+	}
+}
@@ -0,0 +1,181 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package replay reads recorded traffic to be sent again with the original timing
+package replay
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jjmrocha/beast/template"
+)
+
+// Entry is a recorded request
+type Entry struct {
+	// Time elapsed since the first recorded request
+	Offset  time.Duration
+	Request template.Template
+}
+
+// Record is a line of a request log, headers and body are optional
+type Record struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Method    string            `json:"method"`
+	Endpoint  string            `json:"url"`
+	Headers   []template.Header `json:"headers,omitempty"`
+	Body      string            `json:"body,omitempty"`
+}
+
+// Format of the timestamps in the output file of `beast run`
+const datetimeFormat = "2006-01-02 15:04:05.999"
+
+// Read reads the requests recorded on a file, ordered by time,
+// files with the extension ".csv" are read as output files of `beast run -output ...`,
+// other files as request logs with one JSON Record per line
+func Read(fileName string) ([]Entry, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []Record
+	if strings.HasSuffix(strings.ToLower(fileName), ".csv") {
+		records, err = readOutput(file)
+	} else {
+		records, err = readLog(file)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid replay file %s: %v", fileName, err)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("replay file %s has no requests", fileName)
+	}
+
+	return entries(records), nil
+}
+
+func entries(records []Record) []Entry {
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+
+	first := records[0].Timestamp
+	result := make([]Entry, 0, len(records))
+
+	for _, record := range records {
+		result = append(result, Entry{
+			Offset: record.Timestamp.Sub(first),
+			Request: template.Template{
+				Method:   record.Method,
+				Endpoint: record.Endpoint,
+				Headers:  record.Headers,
+				Body:     record.Body,
+			},
+		})
+	}
+
+	return result
+}
+
+// readOutput uses the "Timestamp" and "Request" columns, the request has the format "<method> <url>"
+func readOutput(reader io.Reader) ([]Record, error) {
+	csvReader := csv.NewReader(reader)
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	timestampColumn, requestColumn := -1, -1
+	for i, column := range header {
+		switch column {
+		case "Timestamp":
+			timestampColumn = i
+		case "Request":
+			requestColumn = i
+		}
+	}
+
+	if timestampColumn < 0 || requestColumn < 0 {
+		return nil, fmt.Errorf("columns 'Timestamp' and 'Request' are required")
+	}
+
+	records := make([]Record, 0)
+
+	for line := 2; ; line++ {
+		row, err := csvReader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		parts := strings.SplitN(row[requestColumn], " ", 2)
+		// Requests that couldn't be generated weren't sent
+		if len(parts) != 2 {
+			continue
+		}
+
+		timestamp, err := time.ParseInLocation(datetimeFormat, row[timestampColumn], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %v", line, err)
+		}
+
+		records = append(records, Record{
+			Timestamp: timestamp,
+			Method:    parts[0],
+			Endpoint:  parts[1],
+		})
+	}
+}
+
+func readLog(reader io.Reader) ([]Record, error) {
+	records := make([]Record, 0)
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			return nil, fmt.Errorf("line %v: %v", line, err)
+		}
+
+		if record.Method == "" || record.Endpoint == "" || record.Timestamp.IsZero() {
+			return nil, fmt.Errorf("line %v: 'timestamp', 'method' and 'url' are required", line)
+		}
+
+		records = append(records, record)
+	}
+
+	return records, scanner.Err()
+}
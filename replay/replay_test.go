@@ -0,0 +1,116 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package replay
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadOutput(t *testing.T) {
+	// given
+	csv := `Timestamp,Request,Result,StatusCode,IsSuccess,Duration,Warmup
+2020-07-07 23:03:33.5,GET http://localhost/b,Executed,200,true,10,false
+2020-07-07 23:03:33,GET http://localhost/a,Executed,200,true,10,false
+2020-07-07 23:03:34,--,Request generation error,,false,,false
+2020-07-07 23:03:35.25,POST http://localhost/c,Executed,201,true,10,false
+`
+	// when
+	records, err := readOutput(strings.NewReader(csv))
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := entries(records)
+	var expected = []struct {
+		offset   time.Duration
+		method   string
+		endpoint string
+	}{
+		{0, "GET", "http://localhost/a"},
+		{500 * time.Millisecond, "GET", "http://localhost/b"},
+		{2250 * time.Millisecond, "POST", "http://localhost/c"},
+	}
+
+	if len(result) != len(expected) {
+		t.Fatalf("got %v entries expected %v", len(result), len(expected))
+	}
+
+	for i, test := range expected {
+		entry := result[i]
+		if entry.Offset != test.offset || entry.Request.Method != test.method || entry.Request.Endpoint != test.endpoint {
+			t.Errorf("got %v %v %v expected %v %v %v", entry.Offset, entry.Request.Method, entry.Request.Endpoint, test.offset, test.method, test.endpoint)
+		}
+	}
+}
+
+func TestReadOutputWithoutColumns(t *testing.T) {
+	// given
+	csv := "Result,StatusCode\nExecuted,200\n"
+	// when
+	_, err := readOutput(strings.NewReader(csv))
+	// then
+	if err == nil {
+		t.Error("expected error for missing columns")
+	}
+}
+
+func TestReadLog(t *testing.T) {
+	// given
+	log := `{"timestamp": "2020-07-07T23:03:33Z", "method": "POST", "url": "http://localhost/a", "headers": [{"key": "Content-Type", "value": "application/json"}], "body": "{\"id\": 1}"}
+
+{"timestamp": "2020-07-07T23:03:34.1Z", "method": "GET", "url": "http://localhost/b"}
+`
+	// when
+	records, err := readLog(strings.NewReader(log))
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := entries(records)
+	if len(result) != 2 {
+		t.Fatalf("got %v entries expected 2", len(result))
+	}
+
+	first := result[0].Request
+	if first.Body != `{"id": 1}` || len(first.Headers) != 1 || first.Headers[0].Value != "application/json" {
+		t.Errorf("unexpected request %+v", first)
+	}
+
+	if result[1].Offset != 1100*time.Millisecond {
+		t.Errorf("got offset %v expected %v", result[1].Offset, 1100*time.Millisecond)
+	}
+}
+
+func TestReadLogInvalid(t *testing.T) {
+	// given
+	var tests = []string{
+		`{"method": "GET", "url": "http://localhost/a"}`,
+		`{"timestamp": "2020-07-07T23:03:33Z", "url": "http://localhost/a"}`,
+		`not json`,
+	}
+	// then
+	for _, test := range tests {
+		_, err := readLog(strings.NewReader(test))
+		if err == nil {
+			t.Errorf("expected error for %v", test)
+		}
+	}
+}
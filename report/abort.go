@@ -0,0 +1,132 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jjmrocha/beast/client"
+)
+
+// AbortCriteria defines the conditions used to stop a test before the end
+type AbortCriteria struct {
+	// Maximum percentage of non successful requests in the window, zero for no limit
+	ErrorRate float64
+	// Maximum p99 of the requests in the window, zero for no limit
+	Latency time.Duration
+	// Number of requests used to evaluate ErrorRate and Latency
+	Window int
+	// Maximum number of consecutive connection errors, zero for no limit
+	ConnectionErrors int
+}
+
+// IsDefined returns true if at least one of the criteria is used
+func (a AbortCriteria) IsDefined() bool {
+	return a.ErrorRate > 0 || a.Latency > 0 || a.ConnectionErrors > 0
+}
+
+// Monitor checks the responses against the abort criteria
+type Monitor struct {
+	criteria         AbortCriteria
+	durations        durationSlice
+	failed           []bool
+	next             int
+	full             bool
+	failedCount      int
+	connectionErrors int
+}
+
+// NewMonitor creates a Monitor for the criteria
+func NewMonitor(criteria AbortCriteria) *Monitor {
+	return &Monitor{
+		criteria:  criteria,
+		durations: make(durationSlice, criteria.Window),
+		failed:    make([]bool, criteria.Window),
+	}
+}
+
+// Check adds the response to the sliding window and returns true
+// and the reason if the test must be aborted
+func (m *Monitor) Check(response *client.Response) (string, bool) {
+	if m.criteria.ConnectionErrors > 0 {
+		// Other client errors, e.g. timeouts or requests that failed to be generated,
+		// don't tell if the connection works
+		if response.IsConnectionError() {
+			m.connectionErrors++
+		} else if !response.IsClientError() {
+			m.connectionErrors = 0
+		}
+
+		if m.connectionErrors >= m.criteria.ConnectionErrors {
+			return fmt.Sprintf("%v consecutive connection errors", m.connectionErrors), true
+		}
+	}
+
+	// Requests without credentials weren't sent to the endpoint
+	// and requests cancelled when the test ends say nothing about it
+	if m.criteria.Window == 0 || response.StatusCode == client.AuthFailure || response.StatusCode == client.Cancelled {
+		return "", false
+	}
+
+	m.add(response)
+
+	if !m.full {
+		return "", false
+	}
+
+	if m.criteria.ErrorRate > 0 {
+		errorRate := float64(m.failedCount) * 100 / float64(m.criteria.Window)
+		if errorRate > m.criteria.ErrorRate {
+			return fmt.Sprintf("%.2f%% of the last %v requests failed", errorRate, m.criteria.Window), true
+		}
+	}
+
+	// Sorting the window is expensive, so the latency is checked once per window
+	if m.criteria.Latency > 0 && m.next == 0 {
+		sorted := make(durationSlice, m.criteria.Window)
+		copy(sorted, m.durations)
+		sort.Sort(sorted)
+
+		if p99 := sorted.percentage(99); p99 > m.criteria.Latency {
+			return fmt.Sprintf("p99 of %v for the last %v requests", p99, m.criteria.Window), true
+		}
+	}
+
+	return "", false
+}
+
+func (m *Monitor) add(response *client.Response) {
+	if m.failed[m.next] {
+		m.failedCount--
+	}
+
+	failed := !response.IsSuccess()
+	if failed {
+		m.failedCount++
+	}
+
+	m.failed[m.next] = failed
+	m.durations[m.next] = response.Duration
+	m.next++
+
+	if m.next == m.criteria.Window {
+		m.next = 0
+		m.full = true
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jjmrocha/beast/client"
+)
+
+func TestMonitorErrorRate(t *testing.T) {
+	// given
+	monitor := NewMonitor(AbortCriteria{ErrorRate: 50, Window: 4})
+	var tests = []struct {
+		status   int
+		expected bool
+	}{
+		{500, false},
+		{500, false},
+		{500, false},
+		{200, true},
+		{200, false},
+		{200, false},
+		{500, false},
+	}
+	// then
+	for i, test := range tests {
+		_, result := monitor.Check(&client.Response{StatusCode: test.status})
+		if result != test.expected {
+			t.Errorf("got %v expected %v for response %v", result, test.expected, i)
+		}
+	}
+}
+
+func TestMonitorCancelled(t *testing.T) {
+	// given
+	monitor := NewMonitor(AbortCriteria{ErrorRate: 20, Latency: 100 * time.Millisecond, Window: 4})
+	var tests = []struct {
+		status   int
+		duration time.Duration
+		expected bool
+	}{
+		{200, 10 * time.Millisecond, false},
+		{200, 10 * time.Millisecond, false},
+		{200, 10 * time.Millisecond, false},
+		{client.Cancelled, 30 * time.Second, false},
+		{client.Cancelled, 30 * time.Second, false},
+		{200, 10 * time.Millisecond, false},
+		{500, 10 * time.Millisecond, true},
+	}
+	// then
+	for i, test := range tests {
+		_, result := monitor.Check(&client.Response{StatusCode: test.status, Duration: test.duration})
+		if result != test.expected {
+			t.Errorf("got %v expected %v for response %v", result, test.expected, i)
+		}
+	}
+}
+
+func TestMonitorLatency(t *testing.T) {
+	// given
+	monitor := NewMonitor(AbortCriteria{Latency: 100 * time.Millisecond, Window: 2})
+	var tests = []struct {
+		duration time.Duration
+		expected bool
+	}{
+		{10 * time.Millisecond, false},
+		{200 * time.Millisecond, false},
+		{200 * time.Millisecond, false},
+		{200 * time.Millisecond, true},
+	}
+	// then
+	for i, test := range tests {
+		_, result := monitor.Check(&client.Response{StatusCode: 200, Duration: test.duration})
+		if result != test.expected {
+			t.Errorf("got %v expected %v for response %v", result, test.expected, i)
+		}
+	}
+}
+
+func TestMonitorConnectionErrors(t *testing.T) {
+	// given
+	monitor := NewMonitor(AbortCriteria{ConnectionErrors: 2, Window: 100})
+	var tests = []struct {
+		status   int
+		expected bool
+	}{
+		{client.ConnectionRefused, false},
+		{500, false},
+		{client.DNSFailure, false},
+		{client.GenerationError, false},
+		{client.HeaderTimeout, false},
+		{client.AuthFailure, false},
+		{client.UnexpectedError, false},
+		{client.TLSFailure, true},
+	}
+	// then
+	for i, test := range tests {
+		_, result := monitor.Check(&client.Response{StatusCode: test.status})
+		if result != test.expected {
+			t.Errorf("got %v expected %v for response %v", result, test.expected, i)
+		}
+	}
+}
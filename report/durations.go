@@ -43,6 +43,10 @@ func (a durationSlice) last() time.Duration {
 func (a durationSlice) percentage(value int) time.Duration {
 	size := len(a)
 	pos := (size * value / 100) - 1
+	if pos < 0 {
+		pos = 0
+	}
+
 	return a[pos]
 }
 
@@ -53,3 +53,27 @@ func TestPercentage(t *testing.T) {
 		}
 	}
 }
+
+func TestPercentageWithFewElements(t *testing.T) {
+	// given
+	ds := durationSlice{
+		time.Duration(3),
+		time.Duration(7),
+	}
+	var tests = []struct {
+		input    int
+		expected time.Duration
+	}{
+		{20, time.Duration(3)},
+		{50, time.Duration(3)},
+		{99, time.Duration(3)},
+		{100, time.Duration(7)},
+	}
+	// then
+	for _, test := range tests {
+		result := ds.percentage(test.input)
+		if result != test.expected {
+			t.Errorf("got %v expected %v for percentage %v", result, test.expected, test.input)
+		}
+	}
+}
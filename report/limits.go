@@ -0,0 +1,89 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Limits defines the maximum latency percentile and error rate accepted for a test
+type Limits struct {
+	// Percentile used to check the Latency, for example 99 for p99
+	Percentile int
+	// Maximum latency for the percentile, zero means no limit
+	Latency time.Duration
+	// Maximum percentage of non successful requests
+	ErrorRate float64
+}
+
+// Check verifies if the stats are within the limits,
+// returning false and the description of the broken limit if not
+func (l Limits) Check(s *Stats) (string, bool) {
+	if s.requests == 0 {
+		return "no requests were executed", false
+	}
+
+	if errorRate := s.errorRate(); errorRate > l.ErrorRate {
+		return fmt.Sprintf("error rate of %.2f%% above %.2f%%", errorRate, l.ErrorRate), false
+	}
+
+	if l.Latency > 0 {
+		if latency := s.percentile(l.Percentile); latency > l.Latency {
+			return fmt.Sprintf("p%v of %v above %v", l.Percentile, latency, l.Latency), false
+		}
+	}
+
+	return "", true
+}
+
+// Summary describes the stats using the values checked by the limits
+func (l Limits) Summary(s *Stats) string {
+	return fmt.Sprintf("%v requests, p%v of %v, error rate of %.2f%%", s.requests, l.Percentile, s.percentile(l.Percentile), s.errorRate())
+}
+
+// errorRate returns the percentage of non successful requests
+func (s *Stats) errorRate() float64 {
+	if s.requests == 0 {
+		return 0
+	}
+
+	success := 0
+
+	for _, durations := range s.successMap {
+		success += durations.Len()
+	}
+
+	return float64(s.requests-success) * 100 / float64(s.requests)
+}
+
+// percentile returns the response time of the successful requests for the percentile
+func (s *Stats) percentile(value int) time.Duration {
+	durations := make(durationSlice, 0, s.requests)
+
+	for _, values := range s.successMap {
+		durations = append(durations, values...)
+	}
+
+	if durations.Len() == 0 {
+		return 0
+	}
+
+	sort.Sort(durations)
+	return durations.percentage(value)
+}
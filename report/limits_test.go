@@ -0,0 +1,77 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jjmrocha/beast/client"
+)
+
+type mockedProgress struct{}
+
+func (mockedProgress) Update() {}
+
+func buildStats(responses ...client.Response) *Stats {
+	stats, _ := NewStats(mockedProgress{}, "", nil, 0)
+
+	for i := range responses {
+		stats.Update(&responses[i])
+	}
+
+	return stats
+}
+
+func TestLimitsCheck(t *testing.T) {
+	// given
+	stats := buildStats(
+		client.Response{StatusCode: 200, Duration: 10 * time.Millisecond},
+		client.Response{StatusCode: 200, Duration: 20 * time.Millisecond},
+		client.Response{StatusCode: 200, Duration: 30 * time.Millisecond},
+		client.Response{StatusCode: 500, Duration: 5 * time.Millisecond},
+	)
+	var tests = []struct {
+		limits   Limits
+		expected bool
+	}{
+		{Limits{Percentile: 99, Latency: 0, ErrorRate: 25}, true},
+		{Limits{Percentile: 99, Latency: 0, ErrorRate: 20}, false},
+		{Limits{Percentile: 99, Latency: 30 * time.Millisecond, ErrorRate: 30}, true},
+		{Limits{Percentile: 99, Latency: 15 * time.Millisecond, ErrorRate: 30}, false},
+		{Limits{Percentile: 50, Latency: 10 * time.Millisecond, ErrorRate: 30}, true},
+	}
+	// then
+	for _, test := range tests {
+		reason, result := test.limits.Check(stats)
+		if result != test.expected {
+			t.Errorf("got %v (%v) expected %v for %+v", result, reason, test.expected, test.limits)
+		}
+	}
+}
+
+func TestLimitsCheckWithoutRequests(t *testing.T) {
+	// given
+	stats := buildStats()
+	limits := Limits{Percentile: 99, ErrorRate: 100}
+	// when
+	_, result := limits.Check(stats)
+	// then
+	if result {
+		t.Errorf("got %v expected %v", result, false)
+	}
+}
@@ -34,20 +34,25 @@ type Output struct {
 }
 
 // NewOutput creates new Output handler
-func NewOutput(fileName string) Output {
+func NewOutput(fileName string) (Output, error) {
 	if fileName == "" {
-		return Output{}
+		return Output{}, nil
+	}
+
+	csvReport, err := newCsvReport(fileName)
+	if err != nil {
+		return Output{}, err
 	}
 
 	output := Output{
-		csvReport:     newCsvReport(fileName),
+		csvReport:     csvReport,
 		outputChannel: make(chan *client.Response, 1024),
 		buffer:        control.NewFifo(),
 		closeFile:     control.NewWaitCompletion(),
 	}
 
 	go asyncWrite(output)
-	return output
+	return output, nil
 }
 
 // Write appends the response to the output file
@@ -59,13 +64,20 @@ func (o Output) Write(response *client.Response) {
 	o.outputChannel <- response
 }
 
-// Close closes the CSV file
+// Close writes the pending responses and closes the CSV file
 func (o Output) Close() {
 	if !o.isInUse() {
 		return
 	}
 
 	o.closeFile.Request()
+}
+
+func (o Output) print() {
+	if !o.isInUse() {
+		return
+	}
+
 	fmt.Printf("===== Output File =====\n")
 	fmt.Printf("Output file '%s' was successfully generated\n", o.csvReport.FileName)
 }
@@ -0,0 +1,42 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"time"
+
+	"github.com/jjmrocha/beast/client"
+)
+
+// Names of the phases of a request, in the order returned by phaseDurations
+var phaseNames = []string{
+	"DNS lookup",
+	"TCP connect",
+	"TLS handshake",
+	"Time to first byte",
+	"Body download",
+}
+
+func phaseDurations(phases client.Phases) []time.Duration {
+	return []time.Duration{
+		phases.DNS,
+		phases.Connect,
+		phases.TLS,
+		phases.FirstByte,
+		phases.Download,
+	}
+}
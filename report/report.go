@@ -18,9 +18,10 @@ package report
 
 import (
 	"encoding/csv"
-	"log"
+	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/jjmrocha/beast/client"
 )
@@ -31,10 +32,10 @@ type csvReport struct {
 	writer   *csv.Writer
 }
 
-func newCsvReport(fileName string) *csvReport {
+func newCsvReport(fileName string) (*csvReport, error) {
 	fileHandler, err := os.Create(fileName)
 	if err != nil {
-		log.Fatalf("Error creating output file %s: %v\n", fileName, err)
+		return nil, fmt.Errorf("error creating output file %s: %v", fileName, err)
 	}
 
 	csvWriter := csv.NewWriter(fileHandler)
@@ -44,7 +45,7 @@ func newCsvReport(fileName string) *csvReport {
 		FileName: fileName,
 		file:     fileHandler,
 		writer:   csvWriter,
-	}
+	}, nil
 }
 
 func (csv *csvReport) write(response *client.Response) {
@@ -65,6 +66,18 @@ func csvHeader() []string {
 		"StatusCode",
 		"IsSuccess",
 		"Duration",
+		"Warmup",
+		"Template",
+		"Error",
+		"FailedCheck",
+		"Protocol",
+		"DNS",
+		"Connect",
+		"TLS",
+		"FirstByte",
+		"Download",
+		"RequestSize",
+		"ResponseSize",
 	}
 }
 
@@ -75,6 +88,7 @@ func encode(response *client.Response) []string {
 	var statusCode = ""
 	var isSuccess = "false"
 	var duration = ""
+	var warmup = strconv.FormatBool(response.Warmup)
 
 	if response.Duration.Nanoseconds() > 0 {
 		duration = strconv.FormatInt(response.Duration.Milliseconds(), 10)
@@ -90,12 +104,38 @@ func encode(response *client.Response) []string {
 		}
 	}
 
-	return []string{
+	record := []string{
 		timestamp,
 		request,
 		result,
 		statusCode,
 		isSuccess,
 		duration,
+		warmup,
+		response.Template,
+		response.Error,
+		response.FailedCheck,
+		response.Protocol,
+	}
+
+	for _, duration := range phaseDurations(response.Phases) {
+		record = append(record, formatPhase(duration))
 	}
+
+	if response.IsClientError() {
+		record = append(record, "", "")
+	} else {
+		record = append(record, strconv.FormatInt(response.RequestSize, 10), strconv.FormatInt(response.ResponseSize, 10))
+	}
+
+	return record
+}
+
+// formatPhase returns the duration in milliseconds with microsecond precision, empty if the phase didn't happen
+func formatPhase(duration time.Duration) string {
+	if duration == 0 {
+		return ""
+	}
+
+	return strconv.FormatFloat(float64(duration.Microseconds())/1000, 'f', 3, 64)
 }
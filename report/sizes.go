@@ -0,0 +1,89 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"fmt"
+	"sort"
+)
+
+type sizeSlice []int64
+
+func (a sizeSlice) Len() int {
+	return len(a)
+}
+
+func (a sizeSlice) Less(i, j int) bool {
+	return a[i] < a[j]
+}
+
+func (a sizeSlice) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+func (a sizeSlice) percentage(value int) int64 {
+	size := len(a)
+	pos := (size * value / 100) - 1
+	if pos < 0 {
+		pos = 0
+	}
+
+	return a[pos]
+}
+
+func (a sizeSlice) sum() int64 {
+	var sum int64
+
+	for _, value := range a {
+		sum += value
+	}
+
+	return sum
+}
+
+// SizeDistribution contains the sizes in bytes, headers included, of a set of requests or responses by percentile
+type SizeDistribution struct {
+	Min int64
+	P50 int64
+	P90 int64
+	P99 int64
+	Max int64
+}
+
+func sizeDistribution(sizes sizeSlice) SizeDistribution {
+	if len(sizes) == 0 {
+		return SizeDistribution{}
+	}
+
+	sort.Sort(sizes)
+	return SizeDistribution{
+		Min: sizes[0],
+		P50: sizes.percentage(50),
+		P90: sizes.percentage(90),
+		P99: sizes.percentage(99),
+		Max: sizes[len(sizes)-1],
+	}
+}
+
+func (d SizeDistribution) String() string {
+	return fmt.Sprintf("min %v, p50 %v, p90 %v, p99 %v, max %v bytes", d.Min, d.P50, d.P90, d.P99, d.Max)
+}
+
+// megabytes converts bytes to megabytes (10^6 bytes)
+func megabytes(bytes int64) float64 {
+	return float64(bytes) / 1e6
+}
@@ -0,0 +1,34 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import "testing"
+
+func TestSizeDistribution(t *testing.T) {
+	// given
+	sizes := make(sizeSlice, 0, 100)
+	for i := 100; i > 0; i-- {
+		sizes = append(sizes, int64(i))
+	}
+	expected := SizeDistribution{Min: 1, P50: 50, P90: 90, P99: 99, Max: 100}
+	// when
+	result := sizeDistribution(sizes)
+	// then
+	if result != expected {
+		t.Errorf("got %+v expected %+v", result, expected)
+	}
+}
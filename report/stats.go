@@ -19,9 +19,11 @@ package report
 import (
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/jjmrocha/beast/client"
+	"github.com/jjmrocha/beast/control"
 )
 
 // Progress defines the progress indicator interface used by stats collector to inform user of the execution progress
@@ -31,35 +33,134 @@ type Progress interface {
 
 // Stats collects statistics about the results of the execution
 type Stats struct {
-	concurrent     int
+	mutex          sync.Mutex
 	requests       int
+	warmupRequests int
+	cancelled      int
+	authFailures   int
+	authSample     string
+	warmup         time.Duration
+	warmupEnd      time.Time
+	agentWarmup    bool
 	executionStart time.Time
 	duration       time.Duration
 	successMap     map[int]durationSlice
 	statusMap      map[int]int
 	errorMap       map[string]int
+	errorSamples   map[string]string
+	checkMap       map[string]int
+	protocolMap    map[string]int
+	phases         []durationSlice
+	requestSizes   sizeSlice
+	responseSizes  sizeSlice
+	stages         []control.Stage
+	stageMap       map[int]*groupStats
+	templateMap    map[string]*groupStats
+	stopReason     string
 	progress       Progress
 	output         Output
 }
 
-// NewStats creates a new Stats
-func NewStats(nParallel int, progress Progress, outputFile string) *Stats {
+// groupStats contains the results of the requests of a stage or template
+type groupStats struct {
+	requests  int
+	failed    int
+	durations durationSlice
+}
+
+// NewStats creates a new Stats, when stages are provided the results are also reported by stage,
+// requests sent during the warmup are written to the output file but excluded from the stats
+func NewStats(progress Progress, outputFile string, stages []control.Stage, warmup time.Duration) (*Stats, error) {
+	output, err := NewOutput(outputFile)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
 	return &Stats{
-		concurrent:     nParallel,
-		executionStart: time.Now(),
+		warmup:         warmup,
+		warmupEnd:      now.Add(warmup),
+		executionStart: now,
 		successMap:     make(map[int]durationSlice),
 		statusMap:      make(map[int]int),
 		errorMap:       make(map[string]int),
+		errorSamples:   make(map[string]string),
+		checkMap:       make(map[string]int),
+		protocolMap:    make(map[string]int),
+		phases:         make([]durationSlice, len(phaseNames)),
+		stages:         stages,
+		stageMap:       make(map[int]*groupStats),
+		templateMap:    make(map[string]*groupStats),
 		progress:       progress,
-		output:         NewOutput(outputFile),
-	}
+		output:         output,
+	}, nil
+}
+
+// UseAgentWarmup makes the stats use the warm-up marked in the responses by the agents of a distributed test,
+// the timestamps of the agents come from other clocks and can't be compared with the end of the warm-up
+func (s *Stats) UseAgentWarmup() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.agentWarmup = true
 }
 
 // Update receives results and update the stats accordingly
 func (s *Stats) Update(response *client.Response) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	warmup := response.Warmup
+	if !s.agentWarmup {
+		warmup = s.warmup > 0 && response.Timestamp.Before(s.warmupEnd)
+	}
+
+	if warmup {
+		response.Warmup = true
+		s.warmupRequests++
+		s.progress.Update()
+		s.output.Write(response)
+		return
+	}
+
+	// Requests cancelled when the test ends don't say anything about the server
+	if response.StatusCode == client.Cancelled {
+		s.cancelled++
+		s.progress.Update()
+		s.output.Write(response)
+		return
+	}
+
+	// Requests without credentials weren't sent, so they aren't errors of the endpoint
+	if response.StatusCode == client.AuthFailure {
+		s.authFailures++
+		if s.authSample == "" {
+			s.authSample = response.Error
+		}
+
+		s.progress.Update()
+		s.output.Write(response)
+		return
+	}
+
 	s.requests++
 	s.duration += response.Duration
 
+	if response.Protocol != "" {
+		s.protocolMap[response.Protocol]++
+	}
+
+	if !response.IsClientError() {
+		s.requestSizes = append(s.requestSizes, response.RequestSize)
+		s.responseSizes = append(s.responseSizes, response.ResponseSize)
+	}
+
+	// Phases that didn't happen, e.g. connecting using an open connection, aren't included
+	for i, duration := range phaseDurations(response.Phases) {
+		if duration > 0 {
+			s.phases[i] = append(s.phases[i], duration)
+		}
+	}
+
 	if response.IsSuccess() {
 		durations, present := s.successMap[response.StatusCode]
 		if !present {
@@ -70,16 +171,94 @@ func (s *Stats) Update(response *client.Response) {
 	} else if response.IsClientError() {
 		errorDesc := response.ClientError()
 		s.errorMap[errorDesc]++
+
+		if _, present := s.errorSamples[errorDesc]; !present && response.Error != "" {
+			s.errorSamples[errorDesc] = response.Error
+		}
+	} else if response.FailedCheck != "" {
+		s.checkMap[response.FailedCheck]++
 	} else {
 		s.statusMap[response.StatusCode]++
 	}
 
+	if len(s.stages) > 0 {
+		s.stageMap[response.Stage] = updateGroup(s.stageMap[response.Stage], response)
+	}
+
+	if response.Template != "" {
+		s.templateMap[response.Template] = updateGroup(s.templateMap[response.Template], response)
+	}
+
 	s.progress.Update()
 	s.output.Write(response)
 }
 
+func updateGroup(group *groupStats, response *client.Response) *groupStats {
+	if group == nil {
+		group = &groupStats{durations: make(durationSlice, 0)}
+	}
+
+	group.requests++
+	group.durations = append(group.durations, response.Duration)
+
+	if !response.IsSuccess() {
+		group.failed++
+	}
+
+	return group
+}
+
+// Stopped records the reason why the test was stopped before the end
+func (s *Stats) Stopped(reason string) {
+	s.stopReason = reason
+}
+
+// Snapshot contains the live counters of a test in execution
+type Snapshot struct {
+	Requests          int     `json:"requests"`
+	Successful        int     `json:"successful"`
+	Failed            int     `json:"failed"`
+	WarmupRequests    int     `json:"warmup-requests"`
+	CancelledRequests int     `json:"cancelled-requests"`
+	AuthFailures      int     `json:"auth-failures"`
+	RequestsPerSecond float64 `json:"requests-per-second"`
+	AvgResponseTime   string  `json:"avg-response-time"`
+	Elapsed           string  `json:"elapsed"`
+}
+
+// Snapshot returns the current counters, it can be called while the stats are updated
+func (s *Stats) Snapshot() Snapshot {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	successful := 0
+	for _, durations := range s.successMap {
+		successful += len(durations)
+	}
+
+	snapshot := Snapshot{
+		Requests:          s.requests,
+		Successful:        successful,
+		Failed:            s.requests - successful,
+		WarmupRequests:    s.warmupRequests,
+		CancelledRequests: s.cancelled,
+		AuthFailures:      s.authFailures,
+		AvgResponseTime:   s.avg().String(),
+		Elapsed:           s.executionDuration().Round(time.Millisecond).String(),
+	}
+
+	if s.requests > 0 {
+		snapshot.RequestsPerSecond = s.tps()
+	}
+
+	return snapshot
+}
+
+// tps uses the elapsed time, because with a request rate
+// the number of concurrent requests doesn't define the throughput,
+// it is zero when there are no measured requests, e.g. the test was interrupted during the warm-up
 func (s *Stats) tps() float64 {
-	return float64(s.concurrent) * (float64(s.requests) / s.duration.Seconds())
+	return perSecond(float64(s.requests), s.measuredDuration())
 }
 
 func (s *Stats) avg() time.Duration {
@@ -90,42 +269,60 @@ func (s *Stats) executionDuration() time.Duration {
 	return time.Since(s.executionStart)
 }
 
+// measuredDuration is the execution time after the warmup
+func (s *Stats) measuredDuration() time.Duration {
+	return time.Since(s.warmupEnd)
+}
+
+func perSecond(value float64, duration time.Duration) float64 {
+	if value == 0 || duration <= 0 {
+		return 0
+	}
+
+	return value / duration.Seconds()
+}
+
 func avg(duration time.Duration, requests int) time.Duration {
+	if requests == 0 {
+		return 0
+	}
+
 	return time.Duration(duration.Nanoseconds() / int64(requests))
 }
 
 // PrintStats displays the stats
 func (s *Stats) PrintStats() {
 	fmt.Printf("===== Stats =====\n")
+	if s.stopReason != "" {
+		fmt.Printf("Test stopped before the end: %v\n", s.stopReason)
+	}
 	fmt.Printf("Executed requests: %v\n", s.requests)
+	if s.warmupRequests > 0 {
+		fmt.Printf("Warm-up requests (not included): %v\n", s.warmupRequests)
+	}
+	if s.cancelled > 0 {
+		fmt.Printf("Cancelled requests (not included): %v\n", s.cancelled)
+	}
+	if s.authFailures > 0 {
+		fmt.Printf("Authentication failures (not included): %v\n", s.authFailures)
+		fmt.Printf("  e.g. %v\n", s.authSample)
+	}
 	fmt.Printf("Time taken to complete: %v\n", s.executionDuration())
-	fmt.Printf("Requests per second: %.4f\n", s.tps())
+	if s.requests > 0 {
+		fmt.Printf("Requests per second: %.4f\n", s.tps())
+	} else {
+		fmt.Printf("Requests per second: no measured requests\n")
+	}
 	fmt.Printf("Avg response time: %v\n", s.avg())
+	s.printProtocols()
+	s.printTransfer()
 
 	for key, durations := range s.successMap {
 		fmt.Printf("===== Status %v =====\n", key)
 		count := durations.Len()
 		duration := durations.sum()
 		fmt.Printf("%v requests, with avg response time of %v\n", count, avg(duration, count))
-		if count >= 5 {
-			sort.Sort(durations)
-			fmt.Printf("And the following distribution:\n")
-			fmt.Printf("- The fastest request took %v\n", durations.first())
-			fmt.Printf("- 20%% of requests under %v\n", durations.percentage(20))
-			fmt.Printf("- 40%% of requests under %v\n", durations.percentage(40))
-			fmt.Printf("- 60%% of requests under %v\n", durations.percentage(60))
-			fmt.Printf("- 80%% of requests under %v\n", durations.percentage(80))
-			if count >= 10 {
-				fmt.Printf("- 90%% of requests under %v\n", durations.percentage(90))
-				if count >= 20 {
-					fmt.Printf("- 95%% of requests under %v\n", durations.percentage(95))
-					if count >= 100 {
-						fmt.Printf("- 99%% of requests under %v\n", durations.percentage(99))
-					}
-				}
-			}
-			fmt.Printf("- The slowest request took %v\n", durations.last())
-		}
+		printDistribution(durations)
 	}
 
 	if len(s.statusMap) > 0 {
@@ -136,13 +333,143 @@ func (s *Stats) PrintStats() {
 		}
 	}
 
+	if len(s.checkMap) > 0 {
+		fmt.Printf("===== Failed Checks =====\n")
+
+		for key, value := range s.checkMap {
+			fmt.Printf("- %v: %v requests\n", key, value)
+		}
+	}
+
 	if len(s.errorMap) > 0 {
 		fmt.Printf("===== Errors =====\n")
 
 		for key, value := range s.errorMap {
 			fmt.Printf("- %v: %v errors\n", key, value)
+
+			if sample, present := s.errorSamples[key]; present {
+				fmt.Printf("  e.g. %v\n", sample)
+			}
 		}
 	}
 
+	s.printPhases()
+
+	if len(s.stages) > 0 {
+		s.printStages()
+	}
+
+	if len(s.templateMap) > 0 {
+		s.printTemplates()
+	}
+
+	s.Close()
+	s.output.print()
+}
+
+// Close writes the pending responses to the output file and closes it, must be called once after the last Update
+func (s *Stats) Close() {
 	s.output.Close()
 }
+
+func (s *Stats) printProtocols() {
+	protocols := make([]string, 0, len(s.protocolMap))
+	for protocol := range s.protocolMap {
+		protocols = append(protocols, protocol)
+	}
+
+	sort.Strings(protocols)
+
+	for _, protocol := range protocols {
+		fmt.Printf("Protocol %v: %v requests\n", protocol, s.protocolMap[protocol])
+	}
+}
+
+func (s *Stats) printTransfer() {
+	sent, received := s.requestSizes.sum(), s.responseSizes.sum()
+	if sent == 0 && received == 0 {
+		return
+	}
+
+	fmt.Printf("Data sent: %.3f MB (requests: %v)\n", megabytes(sent), sizeDistribution(s.requestSizes))
+	fmt.Printf("Data received: %.3f MB (responses: %v)\n", megabytes(received), sizeDistribution(s.responseSizes))
+	fmt.Printf("Throughput: %.4f MB/s\n", s.throughput())
+}
+
+// throughput returns the megabytes received per second
+func (s *Stats) throughput() float64 {
+	return perSecond(megabytes(s.responseSizes.sum()), s.measuredDuration())
+}
+
+func (s *Stats) printPhases() {
+	for i, durations := range s.phases {
+		if len(durations) == 0 {
+			continue
+		}
+
+		fmt.Printf("===== Phase %v =====\n", phaseNames[i])
+		count := durations.Len()
+		fmt.Printf("%v requests, with avg time of %v\n", count, avg(durations.sum(), count))
+		printDistribution(durations)
+	}
+}
+
+func (s *Stats) printStages() {
+	for i, stage := range s.stages {
+		fmt.Printf("===== Stage %v: %v =====\n", i+1, stage)
+
+		stats, present := s.stageMap[i]
+		if !present {
+			fmt.Printf("No requests executed\n")
+			continue
+		}
+
+		count := stats.durations.Len()
+		duration := stats.durations.sum()
+		fmt.Printf("%v requests, %v not successful, with avg response time of %v\n", stats.requests, stats.failed, avg(duration, count))
+		printDistribution(stats.durations)
+	}
+}
+
+func (s *Stats) printTemplates() {
+	names := make([]string, 0, len(s.templateMap))
+	for name := range s.templateMap {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		stats := s.templateMap[name]
+		fmt.Printf("===== Template %v =====\n", name)
+		count := stats.durations.Len()
+		duration := stats.durations.sum()
+		fmt.Printf("%v requests (%.2f%%), %v not successful, with avg response time of %v\n", stats.requests, float64(stats.requests)*100/float64(s.requests), stats.failed, avg(duration, count))
+		printDistribution(stats.durations)
+	}
+}
+
+func printDistribution(durations durationSlice) {
+	count := durations.Len()
+	if count < 5 {
+		return
+	}
+
+	sort.Sort(durations)
+	fmt.Printf("And the following distribution:\n")
+	fmt.Printf("- The fastest request took %v\n", durations.first())
+	fmt.Printf("- 20%% of requests under %v\n", durations.percentage(20))
+	fmt.Printf("- 40%% of requests under %v\n", durations.percentage(40))
+	fmt.Printf("- 60%% of requests under %v\n", durations.percentage(60))
+	fmt.Printf("- 80%% of requests under %v\n", durations.percentage(80))
+	if count >= 10 {
+		fmt.Printf("- 90%% of requests under %v\n", durations.percentage(90))
+		if count >= 20 {
+			fmt.Printf("- 95%% of requests under %v\n", durations.percentage(95))
+			if count >= 100 {
+				fmt.Printf("- 99%% of requests under %v\n", durations.percentage(99))
+			}
+		}
+	}
+	fmt.Printf("- The slowest request took %v\n", durations.last())
+}
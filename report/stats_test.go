@@ -0,0 +1,207 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jjmrocha/beast/client"
+)
+
+func TestUpdateWithWarmup(t *testing.T) {
+	// given
+	stats, _ := NewStats(mockedProgress{}, "", nil, time.Minute)
+	warmupResponse := &client.Response{Timestamp: time.Now(), StatusCode: 200}
+	response := &client.Response{Timestamp: time.Now().Add(2 * time.Minute), StatusCode: 200}
+	// when
+	stats.Update(warmupResponse)
+	stats.Update(response)
+	// then
+	if stats.requests != 1 {
+		t.Errorf("got %v expected %v for requests", stats.requests, 1)
+	}
+
+	if stats.warmupRequests != 1 {
+		t.Errorf("got %v expected %v for warmupRequests", stats.warmupRequests, 1)
+	}
+
+	if !warmupResponse.Warmup || response.Warmup {
+		t.Errorf("only the first response should be marked as warm-up")
+	}
+
+	// The warm-up didn't end, so no time was measured
+	if tps := stats.tps(); tps != 0 {
+		t.Errorf("got %v expected 0 for tps", tps)
+	}
+}
+
+func TestUpdateWithAgentWarmup(t *testing.T) {
+	// given
+	stats, _ := NewStats(mockedProgress{}, "", nil, time.Minute)
+	stats.UseAgentWarmup()
+	// The clock of the agent is behind, but the warm-up already ended there
+	response := &client.Response{Timestamp: time.Now().Add(-time.Hour), StatusCode: 200}
+	warmupResponse := &client.Response{Timestamp: time.Now(), StatusCode: 200, Warmup: true}
+	// when
+	stats.Update(response)
+	stats.Update(warmupResponse)
+	// then
+	if stats.requests != 1 {
+		t.Errorf("got %v expected %v for requests", stats.requests, 1)
+	}
+
+	if stats.warmupRequests != 1 {
+		t.Errorf("got %v expected %v for warmupRequests", stats.warmupRequests, 1)
+	}
+
+	if response.Warmup {
+		t.Errorf("only the response marked by the agent should be warm-up")
+	}
+}
+
+func TestUpdateExcluded(t *testing.T) {
+	// given
+	stats, _ := NewStats(mockedProgress{}, "", nil, 0)
+	var responses = []*client.Response{
+		{StatusCode: 200, Duration: 10 * time.Millisecond},
+		{StatusCode: client.Cancelled, Duration: 30 * time.Second},
+		{StatusCode: client.AuthFailure, Error: "token endpoint returned 503"},
+	}
+	// when
+	for _, response := range responses {
+		stats.Update(response)
+	}
+	summary := stats.Summary()
+	// then
+	if summary.Requests != 1 || summary.CancelledRequests != 1 || summary.AuthFailures != 1 {
+		t.Errorf("got %v requests, %v cancelled and %v auth failures expected 1, 1 and 1", summary.Requests, summary.CancelledRequests, summary.AuthFailures)
+	}
+
+	if summary.ErrorRate != 0 || len(summary.Errors) != 0 {
+		t.Errorf("got error rate %v and errors %v expected none", summary.ErrorRate, summary.Errors)
+	}
+}
+
+func TestUpdateByTemplate(t *testing.T) {
+	// given
+	stats, _ := NewStats(mockedProgress{}, "", nil, 0)
+	var responses = []*client.Response{
+		{StatusCode: 200, Template: "browse.json", Duration: 10 * time.Millisecond},
+		{StatusCode: 200, Template: "browse.json", Duration: 20 * time.Millisecond},
+		{StatusCode: 500, Template: "checkout.json", Duration: 30 * time.Millisecond},
+	}
+	// when
+	for _, response := range responses {
+		stats.Update(response)
+	}
+
+	summary := stats.Summary()
+	// then
+	browse := summary.Templates["browse.json"]
+	if browse.Requests != 2 || browse.Failed != 0 || browse.AvgResponseTime != 15*time.Millisecond {
+		t.Errorf("got %+v for browse.json", browse)
+	}
+
+	checkout := summary.Templates["checkout.json"]
+	if checkout.Requests != 1 || checkout.Failed != 1 {
+		t.Errorf("got %+v for checkout.json", checkout)
+	}
+
+	if summary.Requests != 3 {
+		t.Errorf("got %v expected 3 requests", summary.Requests)
+	}
+}
+
+func TestUpdateWithClientErrors(t *testing.T) {
+	// given
+	stats, _ := NewStats(mockedProgress{}, "", nil, 0)
+	var responses = []*client.Response{
+		{StatusCode: client.ConnectionRefused, Error: "dial tcp 127.0.0.1:80: connect: connection refused"},
+		{StatusCode: client.ConnectionRefused, Error: "dial tcp 127.0.0.1:81: connect: connection refused"},
+		{StatusCode: client.DNSFailure, Error: "dial tcp: lookup beast.invalid: no such host"},
+	}
+	// when
+	for _, response := range responses {
+		stats.Update(response)
+	}
+
+	summary := stats.Summary()
+	// then
+	if summary.Errors["Connection refused"] != 2 || summary.Errors["DNS failure"] != 1 {
+		t.Errorf("got %v for errors", summary.Errors)
+	}
+
+	if sample := summary.ErrorSamples["Connection refused"]; sample != responses[0].Error {
+		t.Errorf("got %v expected %v for sample", sample, responses[0].Error)
+	}
+}
+
+func TestUpdateWithPhases(t *testing.T) {
+	// given
+	stats, _ := NewStats(mockedProgress{}, "", nil, 0)
+	var responses = []*client.Response{
+		{StatusCode: 200, Phases: client.Phases{Connect: 2 * time.Millisecond, FirstByte: 10 * time.Millisecond}},
+		{StatusCode: 200, Phases: client.Phases{FirstByte: 20 * time.Millisecond, Download: time.Millisecond}},
+	}
+	// when
+	for _, response := range responses {
+		stats.Update(response)
+	}
+
+	summary := stats.Summary()
+	// then
+	if phase := summary.Phases["TCP connect"]; phase.Requests != 1 || phase.AvgTime != 2*time.Millisecond {
+		t.Errorf("got %+v for TCP connect", phase)
+	}
+
+	if phase := summary.Phases["Time to first byte"]; phase.Requests != 2 || phase.AvgTime != 15*time.Millisecond {
+		t.Errorf("got %+v for time to first byte", phase)
+	}
+
+	if _, present := summary.Phases["DNS lookup"]; present {
+		t.Errorf("DNS lookup not expected")
+	}
+}
+
+func TestUpdateWithSizes(t *testing.T) {
+	// given
+	stats, _ := NewStats(mockedProgress{}, "", nil, 0)
+	var responses = []*client.Response{
+		{StatusCode: 200, RequestSize: 10, ResponseSize: 1000},
+		{StatusCode: 200, RequestSize: 10, ResponseSize: 3000},
+		{StatusCode: client.ConnectionRefused, RequestSize: 10},
+	}
+	// when
+	for _, response := range responses {
+		stats.Update(response)
+	}
+
+	summary := stats.Summary()
+	// then
+	if summary.BytesSent != 20 || summary.BytesReceived != 4000 {
+		t.Errorf("got %v sent and %v received expected 20 and 4000", summary.BytesSent, summary.BytesReceived)
+	}
+
+	if summary.ResponseSizes.Min != 1000 || summary.ResponseSizes.Max != 3000 {
+		t.Errorf("got %+v for response sizes", summary.ResponseSizes)
+	}
+
+	if summary.MegabytesPerSecond <= 0 {
+		t.Errorf("got %v expected positive throughput", summary.MegabytesPerSecond)
+	}
+}
@@ -0,0 +1,246 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jjmrocha/beast/control"
+)
+
+// Distribution contains the response times of a set of requests by percentile,
+// percentiles are zero when there are too few requests to compute them
+type Distribution struct {
+	Min time.Duration
+	P20 time.Duration
+	P40 time.Duration
+	P60 time.Duration
+	P80 time.Duration
+	P90 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// StatusSummary contains the results of the requests with a successful status code
+type StatusSummary struct {
+	Requests        int
+	AvgResponseTime time.Duration
+	Distribution    Distribution
+}
+
+// PhaseSummary contains the time spent on a phase by the requests that went through it
+type PhaseSummary struct {
+	Requests     int
+	AvgTime      time.Duration
+	Distribution Distribution
+}
+
+// StageSummary contains the results of the requests sent during a stage
+type StageSummary struct {
+	Stage           control.Stage
+	Requests        int
+	Failed          int
+	AvgResponseTime time.Duration
+	Distribution    Distribution
+}
+
+// TemplateSummary contains the results of the requests of a template chosen from a mix
+type TemplateSummary struct {
+	Requests        int
+	Failed          int
+	AvgResponseTime time.Duration
+	Distribution    Distribution
+}
+
+// Summary contains the results of a test, the same values displayed by PrintStats
+type Summary struct {
+	// Number of requests, excluding the warm-up
+	Requests       int
+	WarmupRequests int
+	// Number of requests in flight cancelled when the test ended, not included in Requests
+	CancelledRequests int
+	// Number of requests not sent because the credentials couldn't be obtained, not included in Requests
+	AuthFailures int
+	// Time since the start of the test
+	Duration          time.Duration
+	RequestsPerSecond float64
+	AvgResponseTime   time.Duration
+	// Percentage of non successful requests
+	ErrorRate float64
+	// Results of successful requests by status code
+	Success map[int]StatusSummary
+	// Number of non successful requests by status code
+	NonSuccess map[int]int
+	// Number of requests with a successful status code that failed a template check, by check
+	FailedChecks map[string]int
+	// Number of requests by client error description
+	Errors map[string]int
+	// Message of one of the errors of each client error description
+	ErrorSamples map[string]string
+	// Number of requests with a response by protocol
+	Protocols map[string]int
+	// Bytes of the requests and responses, headers included, the throughput uses the bytes received
+	BytesSent          int64
+	BytesReceived      int64
+	MegabytesPerSecond float64
+	RequestSizes       SizeDistribution
+	ResponseSizes      SizeDistribution
+	// Time spent on each phase of the requests by phase name, e.g. "DNS lookup"
+	Phases map[string]PhaseSummary
+	Stages []StageSummary
+	// Results by template, when a mix of templates is used
+	Templates map[string]TemplateSummary
+	// Why the test was stopped before the end, empty if it wasn't
+	StopReason string
+}
+
+// Summary returns the results collected so far
+func (s *Stats) Summary() Summary {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	summary := Summary{
+		Requests:          s.requests,
+		WarmupRequests:    s.warmupRequests,
+		CancelledRequests: s.cancelled,
+		AuthFailures:      s.authFailures,
+		Duration:          s.executionDuration(),
+		AvgResponseTime:   s.avg(),
+		ErrorRate:         s.errorRate(),
+		Success:           make(map[int]StatusSummary),
+		NonSuccess:        make(map[int]int),
+		FailedChecks:      make(map[string]int),
+		Errors:            make(map[string]int),
+		ErrorSamples:      make(map[string]string),
+		Protocols:         make(map[string]int),
+		Phases:            make(map[string]PhaseSummary),
+		Templates:         make(map[string]TemplateSummary),
+		StopReason:        s.stopReason,
+	}
+
+	if s.requests > 0 {
+		summary.RequestsPerSecond = s.tps()
+		summary.MegabytesPerSecond = s.throughput()
+	}
+
+	summary.BytesSent = s.requestSizes.sum()
+	summary.BytesReceived = s.responseSizes.sum()
+	summary.RequestSizes = sizeDistribution(s.requestSizes)
+	summary.ResponseSizes = sizeDistribution(s.responseSizes)
+
+	for key, durations := range s.successMap {
+		count := durations.Len()
+		summary.Success[key] = StatusSummary{
+			Requests:        count,
+			AvgResponseTime: avg(durations.sum(), count),
+			Distribution:    distribution(durations),
+		}
+	}
+
+	for key, value := range s.statusMap {
+		summary.NonSuccess[key] = value
+	}
+
+	for key, value := range s.checkMap {
+		summary.FailedChecks[key] = value
+	}
+
+	for key, value := range s.errorMap {
+		summary.Errors[key] = value
+	}
+
+	for i, durations := range s.phases {
+		if count := durations.Len(); count > 0 {
+			summary.Phases[phaseNames[i]] = PhaseSummary{
+				Requests:     count,
+				AvgTime:      avg(durations.sum(), count),
+				Distribution: distribution(durations),
+			}
+		}
+	}
+
+	for key, value := range s.protocolMap {
+		summary.Protocols[key] = value
+	}
+
+	for key, value := range s.errorSamples {
+		summary.ErrorSamples[key] = value
+	}
+
+	for i, stage := range s.stages {
+		stageSummary := StageSummary{Stage: stage}
+
+		if stats, present := s.stageMap[i]; present {
+			count := stats.durations.Len()
+			stageSummary.Requests = stats.requests
+			stageSummary.Failed = stats.failed
+			stageSummary.AvgResponseTime = avg(stats.durations.sum(), count)
+			stageSummary.Distribution = distribution(stats.durations)
+		}
+
+		summary.Stages = append(summary.Stages, stageSummary)
+	}
+
+	for name, stats := range s.templateMap {
+		count := stats.durations.Len()
+		summary.Templates[name] = TemplateSummary{
+			Requests:        stats.requests,
+			Failed:          stats.failed,
+			AvgResponseTime: avg(stats.durations.sum(), count),
+			Distribution:    distribution(stats.durations),
+		}
+	}
+
+	return summary
+}
+
+// distribution uses the same rules as printDistribution
+func distribution(durations durationSlice) Distribution {
+	count := durations.Len()
+	if count == 0 {
+		return Distribution{}
+	}
+
+	sort.Sort(durations)
+	result := Distribution{
+		Min: durations.first(),
+		Max: durations.last(),
+	}
+
+	if count >= 5 {
+		result.P20 = durations.percentage(20)
+		result.P40 = durations.percentage(40)
+		result.P60 = durations.percentage(60)
+		result.P80 = durations.percentage(80)
+	}
+
+	if count >= 10 {
+		result.P90 = durations.percentage(90)
+	}
+
+	if count >= 20 {
+		result.P95 = durations.percentage(95)
+	}
+
+	if count >= 100 {
+		result.P99 = durations.percentage(99)
+	}
+
+	return result
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/jjmrocha/beast/client"
+)
+
+// Checks are assertions verified on the responses with a successful or expected status code,
+// a response failing one of them is counted as a failed check instead of a success
+type Checks struct {
+	// Expected status codes, any 2xx when empty, responses with one of them (e.g. 302 or 404)
+	// are successful when they pass the other checks
+	Status []int `json:"status,omitempty" yaml:"status,omitempty"`
+	// Headers the response must have, with the value when it isn't empty
+	Headers []Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// Text the body must contain
+	BodyContains string `json:"body-contains,omitempty" yaml:"body-contains,omitempty"`
+	// Regular expression the body must match
+	BodyRegex string `json:"body-regex,omitempty" yaml:"body-regex,omitempty"`
+	// Expected values of the JSON body by path, e.g. {"data.status": "ok"}
+	JSON map[string]string `json:"json,omitempty" yaml:"json,omitempty"`
+	// Maximum response time in milliseconds
+	MaxLatency int `json:"max-latency,omitempty" yaml:"max-latency,omitempty"`
+}
+
+type jsonCheck struct {
+	expr  string
+	path  []string
+	value string
+}
+
+type compiledChecks struct {
+	status     []int
+	headers    []Header
+	contains   []byte
+	regex      *regexp.Regexp
+	json       []jsonCheck
+	maxLatency time.Duration
+}
+
+func (c *Checks) compile() (*compiledChecks, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	if c.MaxLatency < 0 {
+		return nil, fmt.Errorf("check 'max-latency' must be zero or positive")
+	}
+
+	checks := &compiledChecks{
+		status:     c.Status,
+		headers:    c.Headers,
+		contains:   []byte(c.BodyContains),
+		json:       make([]jsonCheck, 0, len(c.JSON)),
+		maxLatency: time.Duration(c.MaxLatency) * time.Millisecond,
+	}
+
+	if c.BodyRegex != "" {
+		regex, err := regexp.Compile(c.BodyRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid check 'body-regex': %v", err)
+		}
+
+		checks.regex = regex
+	}
+
+	for expr, value := range c.JSON {
+		checks.json = append(checks.json, jsonCheck{expr: expr, path: parsePath(expr), value: value})
+	}
+
+	// The checks are verified always in the same order
+	sort.Slice(checks.json, func(i, j int) bool {
+		return checks.json[i].expr < checks.json[j].expr
+	})
+
+	return checks, nil
+}
+
+// needsBody returns true if the body and headers of the response are required
+func (c *compiledChecks) needsBody() bool {
+	return len(c.headers) > 0 || len(c.contains) > 0 || c.regex != nil || len(c.json) > 0
+}
+
+// apply verifies the response, unless it is a client error or has a non successful status code that isn't expected
+func (c *compiledChecks) apply(response *client.Response) {
+	if response.IsClientError() {
+		return
+	}
+
+	expected := containsStatus(c.status, response.StatusCode)
+	if !expected && !response.IsSuccess() {
+		return
+	}
+
+	response.ExpectedStatus = expected
+	response.FailedCheck = c.verify(response)
+}
+
+// verify returns the description of the first check the response fails, empty if it passes all
+func (c *compiledChecks) verify(response *client.Response) string {
+	if len(c.status) > 0 && !containsStatus(c.status, response.StatusCode) {
+		return "status"
+	}
+
+	for _, header := range c.headers {
+		value := response.Header.Get(header.Key)
+		if _, present := response.Header[http.CanonicalHeaderKey(header.Key)]; !present || (header.Value != "" && value != header.Value) {
+			return "header " + header.Key
+		}
+	}
+
+	if len(c.contains) > 0 && !bytes.Contains(response.Body, c.contains) {
+		return "body-contains"
+	}
+
+	if c.regex != nil && !c.regex.Match(response.Body) {
+		return "body-regex"
+	}
+
+	for _, check := range c.json {
+		if value, err := jsonValue(response.Body, check.path); err != nil || value == nil || *value != check.value {
+			return "json " + check.expr
+		}
+	}
+
+	if c.maxLatency > 0 && response.Duration > c.maxLatency {
+		return "max-latency"
+	}
+
+	return ""
+}
+
+func containsStatus(status []int, statusCode int) bool {
+	for _, code := range status {
+		if code == statusCode {
+			return true
+		}
+	}
+
+	return false
+}
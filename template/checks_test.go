@@ -0,0 +1,108 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jjmrocha/beast/client"
+)
+
+func TestVerifyChecks(t *testing.T) {
+	// given
+	response := &client.Response{
+		StatusCode: 200,
+		Duration:   50 * time.Millisecond,
+		Body:       []byte(`{"data": {"status": "ok", "count": 3}}`),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}
+	var tests = []struct {
+		checks   Checks
+		expected string
+	}{
+		{Checks{}, ""},
+		{Checks{Status: []int{200, 201}}, ""},
+		{Checks{Status: []int{201}}, "status"},
+		{Checks{Headers: []Header{{Key: "content-type"}}}, ""},
+		{Checks{Headers: []Header{{Key: "Content-Type", Value: "text/plain"}}}, "header Content-Type"},
+		{Checks{Headers: []Header{{Key: "X-Request-Id"}}}, "header X-Request-Id"},
+		{Checks{BodyContains: `"ok"`}, ""},
+		{Checks{BodyContains: "error"}, "body-contains"},
+		{Checks{BodyRegex: `"count": \d+`}, ""},
+		{Checks{BodyRegex: `"error"`}, "body-regex"},
+		{Checks{JSON: map[string]string{"data.status": "ok", "$.data.count": "3"}}, ""},
+		{Checks{JSON: map[string]string{"data.status": "failed"}}, "json data.status"},
+		{Checks{JSON: map[string]string{"data.missing": ""}}, "json data.missing"},
+		{Checks{MaxLatency: 100}, ""},
+		{Checks{MaxLatency: 10}, "max-latency"},
+	}
+	// then
+	for i, test := range tests {
+		checks, err := test.checks.compile()
+		if err != nil {
+			t.Fatalf("Error not expected: %v", err)
+		}
+
+		if result := checks.verify(response); result != test.expected {
+			t.Errorf("got %q expected %q for checks %v", result, test.expected, i)
+		}
+	}
+}
+
+func TestApplyChecks(t *testing.T) {
+	// given
+	var tests = []struct {
+		checks      Checks
+		status      int
+		success     bool
+		failedCheck string
+	}{
+		{Checks{}, 200, true, ""},
+		{Checks{}, 404, false, ""},
+		{Checks{Status: []int{302}}, 302, true, ""},
+		{Checks{Status: []int{302}}, 200, false, "status"},
+		{Checks{Status: []int{302}}, 500, false, ""},
+		{Checks{Status: []int{404}, MaxLatency: 10}, 404, false, "max-latency"},
+		{Checks{Status: []int{404}}, client.ConnectionRefused, false, ""},
+	}
+	// then
+	for i, test := range tests {
+		checks, _ := test.checks.compile()
+		response := &client.Response{StatusCode: test.status, Duration: 50 * time.Millisecond}
+		checks.apply(response)
+
+		if response.IsSuccess() != test.success || response.FailedCheck != test.failedCheck {
+			t.Errorf("got %v and %q expected %v and %q for checks %v", response.IsSuccess(), response.FailedCheck, test.success, test.failedCheck, i)
+		}
+	}
+}
+
+func TestCompileInvalidChecks(t *testing.T) {
+	// given
+	var tests = []Checks{
+		{BodyRegex: "("},
+		{MaxLatency: -1},
+	}
+	// then
+	for i, test := range tests {
+		if _, err := test.compile(); err == nil {
+			t.Errorf("expected error for checks %v", i)
+		}
+	}
+}
@@ -34,17 +34,19 @@ type CompiledTemplate struct {
 	endpoint *txt.Template
 	headers  []compiledHeader
 	body     *txt.Template
+	checks   *compiledChecks
 }
 
-func (c *CompiledTemplate) executeTemplate(requestID int, record *data.Record) (*Template, error) {
-	var context = struct {
-		RequestID int
-		Data      *data.Record
-	}{
-		RequestID: requestID,
-		Data:      record,
-	}
+// requestContext is the data available to the templates
+type requestContext struct {
+	RequestID int
+	UserID    int
+	Iteration int
+	Data      *data.Record
+	Vars      map[string]string
+}
 
+func (c *CompiledTemplate) executeTemplate(context *requestContext) (*Template, error) {
 	tmplf := Template{
 		Method:  c.method,
 		Headers: make([]Header, 0, len(c.headers)),
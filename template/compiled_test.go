@@ -25,8 +25,8 @@ import (
 
 func TestCompileAndExecute(t *testing.T) {
 	// given
-	dt := data.Read("../testdata/data.csv")
-	tmpl := Read("../testdata/template_post.json")
+	dt, _ := data.Read("../testdata/data.csv")
+	tmpl, _ := Read("../testdata/template_post.json")
 	expected := &Template{
 		Method:   "POST",
 		Endpoint: "http://someendpoint.pt/1",
@@ -41,7 +41,7 @@ func TestCompileAndExecute(t *testing.T) {
 		t.Error(err)
 	}
 
-	result, err := tmplc.executeTemplate(1, dt.Next())
+	result, err := tmplc.executeTemplate(&requestContext{RequestID: 1, Data: dt.Next()})
 	if err != nil {
 		t.Error(err)
 	}
@@ -53,8 +53,8 @@ func TestCompileAndExecute(t *testing.T) {
 
 func BenchmarkFromTemplateToClient(b *testing.B) {
 	// given
-	dt := data.Read("../testdata/data.csv")
-	tmpl := Read("../testdata/template_post.json")
+	dt, _ := data.Read("../testdata/data.csv")
+	tmpl, _ := Read("../testdata/template_post.json")
 	// then
 	b.ResetTimer()
 
@@ -63,12 +63,12 @@ func BenchmarkFromTemplateToClient(b *testing.B) {
 		b.Error(err)
 	}
 
-	tmplf, err := tmplc.executeTemplate(1, dt.Next())
+	tmplf, err := tmplc.executeTemplate(&requestContext{RequestID: 1, Data: dt.Next()})
 	if err != nil {
 		b.Error(err)
 	}
 
-	_, err = tmplf.request()
+	_, err = tmplf.Request()
 	if err != nil {
 		b.Error(err)
 	}
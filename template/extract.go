@@ -0,0 +1,189 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jjmrocha/beast/client"
+)
+
+// Sources of the values extracted from responses
+const (
+	FromJSON   = "json"
+	FromRegex  = "regex"
+	FromHeader = "header"
+	FromCookie = "cookie"
+)
+
+// Extract defines a value taken from a response and stored in a variable,
+// available to the templates of the following steps as {{ .Vars.<variable> }},
+// the expression is a path for JSON (e.g. "data.items[0].id"), a regular expression
+// (using the first group when present) or the name of the header or cookie
+type Extract struct {
+	Variable   string `json:"variable" yaml:"variable"`
+	From       string `json:"from" yaml:"from"`
+	Expression string `json:"expression" yaml:"expression"`
+}
+
+type extractor struct {
+	variable string
+	from     string
+	expr     string
+	path     []string
+	regex    *regexp.Regexp
+}
+
+func (e Extract) compile() (*extractor, error) {
+	if e.Variable == "" || e.Expression == "" {
+		return nil, fmt.Errorf("extract requires 'variable' and 'expression'")
+	}
+
+	ext := &extractor{
+		variable: e.Variable,
+		from:     e.From,
+		expr:     e.Expression,
+	}
+
+	switch e.From {
+	case FromJSON:
+		ext.path = parsePath(e.Expression)
+	case FromRegex:
+		regex, err := regexp.Compile(e.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex for '%v': %v", e.Variable, err)
+		}
+
+		ext.regex = regex
+	case FromHeader, FromCookie:
+	default:
+		return nil, fmt.Errorf("'from' of '%v' must be one of: %s, %s, %s or %s", e.Variable, FromJSON, FromRegex, FromHeader, FromCookie)
+	}
+
+	return ext, nil
+}
+
+// parsePath converts "$.data.items[0].id" into ["data", "items", "0", "id"]
+func parsePath(expression string) []string {
+	expression = strings.TrimPrefix(expression, "$")
+	expression = strings.ReplaceAll(expression, "[", ".")
+	expression = strings.ReplaceAll(expression, "]", "")
+
+	path := make([]string, 0)
+	for _, part := range strings.Split(expression, ".") {
+		if part != "" {
+			path = append(path, part)
+		}
+	}
+
+	return path
+}
+
+// extract returns the value for the response, or an error if it isn't present
+func (e *extractor) extract(response *client.Response) (string, error) {
+	switch e.from {
+	case FromJSON:
+		return e.extractJSON(response.Body)
+	case FromRegex:
+		match := e.regex.FindSubmatch(response.Body)
+		if match == nil {
+			break
+		}
+
+		if len(match) > 1 {
+			return string(match[1]), nil
+		}
+
+		return string(match[0]), nil
+	case FromHeader:
+		if values, present := response.Header[http.CanonicalHeaderKey(e.expr)]; present && len(values) > 0 {
+			return values[0], nil
+		}
+	case FromCookie:
+		resp := http.Response{Header: response.Header}
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == e.expr {
+				return cookie.Value, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("%v '%v' not found for variable '%v'", e.from, e.expr, e.variable)
+}
+
+func (e *extractor) extractJSON(body []byte) (string, error) {
+	value, err := jsonValue(body, e.path)
+	if err != nil {
+		return "", fmt.Errorf("invalid JSON response for variable '%v': %v", e.variable, err)
+	}
+
+	if value == nil {
+		return "", fmt.Errorf("json '%v' not found for variable '%v'", e.expr, e.variable)
+	}
+
+	return *value, nil
+}
+
+// jsonValue returns the value of the body in the path, nil if it isn't present,
+// strings are returned without quotes and other values encoded as JSON
+func jsonValue(body []byte, path []string) (*string, error) {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// Keeps the precision of large numbers, like IDs
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	for _, key := range path {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			value = node[key]
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				value = nil
+			} else {
+				value = node[index]
+			}
+		default:
+			value = nil
+		}
+
+		if value == nil {
+			return nil, nil
+		}
+	}
+
+	if text, ok := value.(string); ok {
+		return &text, nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	text := string(encoded)
+	return &text, nil
+}
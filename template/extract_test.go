@@ -0,0 +1,83 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jjmrocha/beast/client"
+)
+
+func TestExtract(t *testing.T) {
+	// given
+	response := &client.Response{
+		StatusCode: 200,
+		Body:       []byte(`{"data": {"token": "abc", "items": [{"id": 12345678901234567}, {"id": 2}], "ok": true}}`),
+		Header: http.Header{
+			"Location":   []string{"/items/7"},
+			"Set-Cookie": []string{"session=s1; Path=/", "other=o1"},
+		},
+	}
+	var tests = []struct {
+		extract  Extract
+		expected string
+		found    bool
+	}{
+		{Extract{"v", FromJSON, "data.token"}, "abc", true},
+		{Extract{"v", FromJSON, "$.data.items[0].id"}, "12345678901234567", true},
+		{Extract{"v", FromJSON, "data.items.1.id"}, "2", true},
+		{Extract{"v", FromJSON, "data.ok"}, "true", true},
+		{Extract{"v", FromJSON, "data.items[2].id"}, "", false},
+		{Extract{"v", FromJSON, "data.missing"}, "", false},
+		{Extract{"v", FromRegex, `"token": "(\w+)"`}, "abc", true},
+		{Extract{"v", FromRegex, `\d{17}`}, "12345678901234567", true},
+		{Extract{"v", FromRegex, `missing`}, "", false},
+		{Extract{"v", FromHeader, "location"}, "/items/7", true},
+		{Extract{"v", FromHeader, "X-Missing"}, "", false},
+		{Extract{"v", FromCookie, "session"}, "s1", true},
+		{Extract{"v", FromCookie, "missing"}, "", false},
+	}
+	// then
+	for _, test := range tests {
+		ext, err := test.extract.compile()
+		if err != nil {
+			t.Fatalf("Error not expected: %v", err)
+		}
+
+		result, err := ext.extract(response)
+		if (err == nil) != test.found || result != test.expected {
+			t.Errorf("got %v (%v) expected %v for %v", result, err, test.expected, test.extract)
+		}
+	}
+}
+
+func TestCompileInvalidExtract(t *testing.T) {
+	// given
+	var tests = []Extract{
+		{"", FromJSON, "data"},
+		{"v", FromJSON, ""},
+		{"v", "body", "data"},
+		{"v", FromRegex, "(unclosed"},
+	}
+	// then
+	for _, test := range tests {
+		if _, err := test.compile(); err == nil {
+			t.Errorf("expected error for %v", test)
+		}
+	}
+}
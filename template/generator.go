@@ -18,34 +18,121 @@ package template
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/jjmrocha/beast/client"
 	"github.com/jjmrocha/beast/data"
 )
 
-// Generator generates BRequests
+// Generator generates the requests of a template or scenario, for a record
 type Generator struct {
-	Template *CompiledTemplate
-	RecordID int
-	Data     *data.Record
+	Template  Executable
+	RecordID  int
+	Data      *data.Record
+	Scheduled time.Time
+	// Virtual user (concurrent request) sending the request and its iteration, both starting at 1
+	UserID    int
+	Iteration int
+	// Variables available to the templates as {{ .Vars.<name> }}, values extracted from the responses
+	// of a scenario are added to it, when nil each generator uses its own variables
+	Vars     map[string]string
+	scenario *CompiledScenario
+	name     string
+	step     int
 }
 
-// Request uses that template and a record and returns a BRequests
+// Request uses that template and a record and returns a BRequests,
+// for scenarios it returns the request of the first step
 func (g *Generator) Request() (*client.Request, error) {
-	tmplf, err := g.Template.executeTemplate(g.RecordID, g.Data)
+	g.scenario, g.name = g.Template.choose()
+	g.step = 0
+	return g.request()
+}
+
+// Next extracts the values of the response and returns the request of the next step of the scenario,
+// returning nil when there are no more steps or the response wasn't successful
+func (g *Generator) Next(response *client.Response) (*client.Request, error) {
+	if !response.IsSuccess() {
+		return nil, nil
+	}
+
+	for _, ext := range g.scenario.steps[g.step].extractors {
+		value, err := ext.extract(response)
+		if err != nil {
+			return nil, err
+		}
+
+		if g.Vars == nil {
+			g.Vars = make(map[string]string)
+		}
+
+		g.Vars[ext.variable] = value
+	}
+
+	// Values extracted on the last step are kept for the next request of the session
+	if g.step+1 >= g.scenario.Steps() {
+		return nil, nil
+	}
+
+	g.step++
+	return g.request()
+}
+
+func (g *Generator) request() (*client.Request, error) {
+	step := &g.scenario.steps[g.step]
+	tmplf, err := step.template.executeTemplate(&requestContext{
+		RequestID: g.RecordID,
+		UserID:    g.UserID,
+		Iteration: g.Iteration,
+		Data:      g.Data,
+		Vars:      g.Vars,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := tmplf.request()
+	req, err := tmplf.Request()
 	if err != nil {
 		return nil, err
 	}
 
+	checks := step.template.checks
+	req.Capture = len(step.extractors) > 0 || (checks != nil && checks.needsBody())
+	if g.step == 0 {
+		req.Scheduled = g.Scheduled
+	}
+
 	return req, nil
 }
 
+// Verify checks the response of the current step, recording the failed check in the response
+func (g *Generator) Verify(response *client.Response) {
+	if checks := g.scenario.steps[g.step].template.checks; checks != nil {
+		checks.apply(response)
+	}
+}
+
+// ForUser sets the virtual user that sends the requests and its iteration
+func (g *Generator) ForUser(userID, iteration int) {
+	g.UserID = userID
+	g.Iteration = iteration
+}
+
+// UseVars replaces the variables of the generator, used to share them between generators
+func (g *Generator) UseVars(vars map[string]string) {
+	g.Vars = vars
+}
+
+// Name returns the name of the template or scenario chosen from a mix, empty if there is only one
+func (g *Generator) Name() string {
+	return g.name
+}
+
 // Log generates a log message for the request
 func (g *Generator) Log() string {
+	if g.step > 0 {
+		return fmt.Sprintf("requestId: %v, step: %v and data: %v", g.RecordID, g.scenario.steps[g.step].name, g.Data)
+	}
+
 	return fmt.Sprintf("requestId: %v and data: %v", g.RecordID, g.Data)
 }
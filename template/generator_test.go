@@ -24,7 +24,7 @@ import (
 
 func TestLog(t *testing.T) {
 	// given
-	dt := data.Read("../testdata/simple.csv")
+	dt, _ := data.Read("../testdata/simple.csv")
 	gnt := &Generator{
 		RecordID: 1,
 		Data:     dt.Next(),
@@ -40,8 +40,8 @@ func TestLog(t *testing.T) {
 
 func TestRequestForDynamic(t *testing.T) {
 	// given
-	dt := data.Read("../testdata/data.csv")
-	tmpl := Read("../testdata/template_post.json")
+	dt, _ := data.Read("../testdata/data.csv")
+	tmpl, _ := Read("../testdata/template_post.json")
 	tmplc, _ := tmpl.Compile()
 	gnt := &Generator{
 		Data:     dt.Next(),
@@ -63,8 +63,8 @@ func TestRequestForDynamic(t *testing.T) {
 
 func BenchmarkRequest(b *testing.B) {
 	// given
-	dt := data.Read("../testdata/data.csv")
-	tmpl := Read("../testdata/template_post.json")
+	dt, _ := data.Read("../testdata/data.csv")
+	tmpl, _ := Read("../testdata/template_post.json")
 	tmplc, _ := tmpl.Compile()
 	gnt := &Generator{
 		Data:     dt.Next(),
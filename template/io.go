@@ -18,30 +18,27 @@ package template
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"path/filepath"
 	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Read reads an HTTP request template from a file
-func Read(fileName string) *Template {
-	if isJSON(fileName) {
-		return readJSON(fileName)
-	}
-
-	return readYAML(fileName)
-}
-
-func readFile(fileName string) []byte {
+func Read(fileName string) (*Template, error) {
 	data, err := ioutil.ReadFile(fileName)
-
 	if err != nil {
-		log.Fatalf("Error reading template file %s: %v\n", fileName, err)
+		return nil, fmt.Errorf("error reading template file %s: %v", fileName, err)
+	}
+
+	if isJSON(fileName) {
+		return readJSON(fileName, data)
 	}
 
-	return data
+	return readYAML(fileName, data)
 }
 
 // Write writes an HTTP request template to a file
@@ -53,6 +50,44 @@ func Write(fileName string, tmpl *Template) {
 	}
 }
 
+// ReadScenario reads a scenario from a JSON/YAML file, including the templates of the steps
+func ReadScenario(fileName string) (*Scenario, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading scenario file %s: %v", fileName, err)
+	}
+
+	var scenario Scenario
+	if isJSON(fileName) {
+		err = json.Unmarshal(data, &scenario)
+	} else {
+		err = yaml.Unmarshal(data, &scenario)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid scenario file %s: %v", fileName, err)
+	}
+
+	if len(scenario.Steps) == 0 {
+		return nil, fmt.Errorf("scenario file %s without steps", fileName)
+	}
+
+	dir := filepath.Dir(fileName)
+	for i := range scenario.Steps {
+		step := &scenario.Steps[i]
+		templateFile := step.TemplateFile
+		if !filepath.IsAbs(templateFile) {
+			templateFile = filepath.Join(dir, templateFile)
+		}
+
+		if step.Request, err = Read(templateFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return &scenario, nil
+}
+
 func writeFile(data []byte, fileName string) {
 	if err := ioutil.WriteFile(fileName, data, 0666); err != nil {
 		log.Printf("Error writing template to file %s: %v\n", fileName, err)
@@ -66,33 +101,36 @@ func isJSON(fileName string) bool {
 	return strings.HasSuffix(lowerCaseFileName, ".json")
 }
 
-func readJSON(fileName string) *Template {
-	data := readFile(fileName)
-
+func readJSON(fileName string, data []byte) (*Template, error) {
 	var tmpl Template
 	if err := json.Unmarshal(data, &tmpl); err != nil {
-		log.Printf("Invalid JSON template file %s: %v\n", fileName, err)
+		return nil, fmt.Errorf("invalid JSON template file %s: %v", fileName, err)
 	}
 
-	if body, read := externalBody(tmpl.Body); read {
+	body, read, err := externalBody(tmpl.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if read {
 		tmpl.Body = body
 	}
 
-	return &tmpl
+	return &tmpl, nil
 }
 
-func externalBody(body string) (string, bool) {
+func externalBody(body string) (string, bool, error) {
 	if strings.HasPrefix(body, "@") {
 		fileName := body[1:]
 		data, err := ioutil.ReadFile(fileName)
 		if err != nil {
-			log.Fatalf("Error reading external body file %s: %v\n", fileName, err)
+			return "", false, fmt.Errorf("error reading external body file %s: %v", fileName, err)
 		}
 
-		return string(data), true
+		return string(data), true, nil
 	}
 
-	return "", false
+	return "", false, nil
 }
 
 func writeJSON(fileName string, tmpl *Template) {
@@ -111,6 +149,7 @@ type templateY struct {
 	Endpoint string            `yaml:"endpoint"`
 	Headers  map[string]string `yaml:"headers,omitempty"`
 	Body     string            `yaml:"request-body,omitempty"`
+	Checks   *Checks           `yaml:"checks,omitempty"`
 }
 
 func toYamlTemplate(tmpl *Template) *templateY {
@@ -119,6 +158,7 @@ func toYamlTemplate(tmpl *Template) *templateY {
 		Endpoint: tmpl.Endpoint,
 		Headers:  toHeaderMap(tmpl.Headers),
 		Body:     tmpl.Body,
+		Checks:   tmpl.Checks,
 	}
 }
 
@@ -142,6 +182,7 @@ func fromYamlTemplate(tmply *templateY) *Template {
 		Endpoint: tmply.Endpoint,
 		Headers:  fromHeaderMap(tmply.Headers),
 		Body:     tmply.Body,
+		Checks:   tmply.Checks,
 	}
 }
 
@@ -174,13 +215,11 @@ func writeYAML(fileName string, tmpl *Template) {
 	writeFile(data, fileName)
 }
 
-func readYAML(fileName string) *Template {
-	data := readFile(fileName)
-
+func readYAML(fileName string, data []byte) (*Template, error) {
 	var tmply templateY
 	if err := yaml.Unmarshal(data, &tmply); err != nil {
-		log.Printf("Invalid YAML template file %s: %v\n", fileName, err)
+		return nil, fmt.Errorf("invalid YAML template file %s: %v", fileName, err)
 	}
 
-	return fromYamlTemplate(&tmply)
+	return fromYamlTemplate(&tmply), nil
 }
@@ -29,7 +29,7 @@ func TestReadBasicGETforJSON(t *testing.T) {
 		Endpoint: "http://www.google.pt",
 	}
 	// when
-	tmpl := Read("../testdata/basic_get.json")
+	tmpl, _ := Read("../testdata/basic_get.json")
 	// then
 	if !reflect.DeepEqual(tmpl, expected) {
 		t.Errorf("got %v expected %v", tmpl, expected)
@@ -43,7 +43,7 @@ func TestReadBasicGETforYAML(t *testing.T) {
 		Endpoint: "http://www.google.pt",
 	}
 	// when
-	tmpl := Read("../testdata/basic_get.yaml")
+	tmpl, _ := Read("../testdata/basic_get.yaml")
 	// then
 	if !reflect.DeepEqual(tmpl, expected) {
 		t.Errorf("got %v expected %v", tmpl, expected)
@@ -61,7 +61,7 @@ func TestReadBasicPOSTforJSON(t *testing.T) {
 		Body: "{\"id\": 1, \"value\": \"any\"}",
 	}
 	// when
-	tmpl := Read("../testdata/basic_post.json")
+	tmpl, _ := Read("../testdata/basic_post.json")
 	// then
 	if !reflect.DeepEqual(tmpl, expected) {
 		t.Errorf("got %v expected %v", tmpl, expected)
@@ -79,7 +79,7 @@ func TestReadBasicPOSTforYAML(t *testing.T) {
 		Body: "{\"id\": 1, \"value\": \"any\"}",
 	}
 	// when
-	tmpl := Read("../testdata/basic_post.yaml")
+	tmpl, _ := Read("../testdata/basic_post.yaml")
 	// then
 	if !reflect.DeepEqual(tmpl, expected) {
 		t.Errorf("got %v expected %v", tmpl, expected)
@@ -97,7 +97,7 @@ func TestReadTemplatePOSTforJSON(t *testing.T) {
 		Body: "{\"id\": {{ .RequestID }}, \"value\": \"{{ .Data.A }}\"}",
 	}
 	// when
-	tmpl := Read("../testdata/template_post.json")
+	tmpl, _ := Read("../testdata/template_post.json")
 	// then
 	if !reflect.DeepEqual(tmpl, expected) {
 		t.Errorf("got %v expected %v", tmpl, expected)
@@ -114,7 +114,7 @@ func TestReadTemplatePOSTforYAML(t *testing.T) {
 	firstBodyField := "\"id\": {{ .RequestID }}"
 	secondBodyField := "\"value\": \"{{ .Data.A }}\""
 	// when
-	tmpl := Read("../testdata/template_post.yaml")
+	tmpl, _ := Read("../testdata/template_post.yaml")
 	// then
 	if tmpl.Method != expectedMethod {
 		t.Errorf("got %v expected %v", tmpl.Method, expectedMethod)
@@ -148,7 +148,7 @@ func TestReadTemplatePOSTWithExternalBody(t *testing.T) {
 		Body: "{\"id\": {{ .RequestID }}, \"value\": \"{{ .Data.A }}\"}",
 	}
 	// when
-	tmpl := Read("../testdata/template_post_external.json")
+	tmpl, _ := Read("../testdata/template_post_external.json")
 	// then
 	if !reflect.DeepEqual(tmpl, expected) {
 		t.Errorf("got %v expected %v", tmpl, expected)
@@ -160,7 +160,7 @@ func TestExternalBodyWithExternalBody(t *testing.T) {
 	body := "@../testdata/body.json"
 	expected := "{\"id\": {{ .RequestID }}, \"value\": \"{{ .Data.A }}\"}"
 	// when
-	response, found := externalBody(body)
+	response, found, _ := externalBody(body)
 	// then
 	if found != true {
 		t.Errorf("got %v expected %v for found", found, true)
@@ -175,7 +175,7 @@ func TestExternalBodyWithoutExternalBody(t *testing.T) {
 	// given
 	body := "{\"id\": {{ .RequestID }}, \"value\": \"{{ .Data.A }}\"}"
 	// when
-	_, found := externalBody(body)
+	_, found, _ := externalBody(body)
 	// then
 	if found != false {
 		t.Errorf("got %v expected %v", found, false)
@@ -286,3 +286,18 @@ func TestPartialRequestConvertion(t *testing.T) {
 		t.Errorf("got %v expected %v", tResult, tRequest)
 	}
 }
+
+func TestReadInvalidFiles(t *testing.T) {
+	// given
+	var tests = []string{
+		"../testdata/missing.json",
+		"../testdata/data.csv",
+	}
+	// then
+	for _, test := range tests {
+		_, err := Read(test)
+		if err == nil {
+			t.Errorf("expected error reading %v", test)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Mix is a set of scenarios (or templates), each request executes one of them chosen by weight
+type Mix struct {
+	Entries []MixEntry
+}
+
+// MixEntry is a scenario of a Mix
+type MixEntry struct {
+	// Name used to report the results of the entry
+	Name string
+	// Relative frequency of the entry, e.g. 70, 25 and 5 for 70%, 25% and 5%
+	Weight   int
+	Scenario *Scenario
+}
+
+// NewMix creates a mix with a single scenario
+func NewMix(scenario *Scenario) *Mix {
+	return &Mix{
+		Entries: []MixEntry{{Weight: 1, Scenario: scenario}},
+	}
+}
+
+// Requests returns the expected number of requests sent when the mix is executed n times
+func (m *Mix) Requests(n int) int {
+	total, steps := 0, 0
+
+	for _, entry := range m.Entries {
+		total += entry.Weight
+		steps += entry.Weight * len(entry.Scenario.Steps)
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return int(math.Round(float64(n) * float64(steps) / float64(total)))
+}
+
+// Compile returns a compiled version of the mix
+func (m *Mix) Compile() (*CompiledMix, error) {
+	if len(m.Entries) == 0 {
+		return nil, fmt.Errorf("mix without templates")
+	}
+
+	mix := &CompiledMix{
+		scenarios: make([]*CompiledScenario, 0, len(m.Entries)),
+		names:     make([]string, 0, len(m.Entries)),
+		limits:    make([]int, 0, len(m.Entries)),
+		random:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	for _, entry := range m.Entries {
+		if entry.Weight <= 0 || entry.Scenario == nil {
+			return nil, fmt.Errorf("template '%v' requires a scenario and a positive weight", entry.Name)
+		}
+
+		scenario, err := entry.Scenario.Compile()
+		if err != nil {
+			if entry.Name != "" {
+				return nil, fmt.Errorf("%v: %v", entry.Name, err)
+			}
+
+			return nil, err
+		}
+
+		mix.total += entry.Weight
+		mix.scenarios = append(mix.scenarios, scenario)
+		mix.names = append(mix.names, entry.Name)
+		mix.limits = append(mix.limits, mix.total)
+	}
+
+	return mix, nil
+}
+
+// CompiledMix is the compiled version of a Mix
+type CompiledMix struct {
+	scenarios []*CompiledScenario
+	names     []string
+	// Cumulative weights
+	limits []int
+	total  int
+	mutex  sync.Mutex
+	random *rand.Rand
+}
+
+func (m *CompiledMix) choose() (*CompiledScenario, string) {
+	if len(m.scenarios) == 1 {
+		return m.scenarios[0], m.names[0]
+	}
+
+	// Generators are used by several goroutines and rand.Rand is not safe for concurrent use
+	m.mutex.Lock()
+	value := m.random.Intn(m.total)
+	m.mutex.Unlock()
+
+	for i, limit := range m.limits {
+		if value < limit {
+			return m.scenarios[i], m.names[i]
+		}
+	}
+
+	return m.scenarios[len(m.scenarios)-1], m.names[len(m.names)-1]
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import "testing"
+
+func buildMix(weights ...int) *Mix {
+	mix := &Mix{}
+
+	for i, weight := range weights {
+		steps := make([]Step, i+1)
+		for j := range steps {
+			steps[j].Request = &Template{Method: "GET", Endpoint: "http://localhost/"}
+		}
+
+		mix.Entries = append(mix.Entries, MixEntry{
+			Name:     string(rune('a' + i)),
+			Weight:   weight,
+			Scenario: &Scenario{Steps: steps},
+		})
+	}
+
+	return mix
+}
+
+func TestMixRequests(t *testing.T) {
+	// given
+	var tests = []struct {
+		weights  []int
+		n        int
+		expected int
+	}{
+		{[]int{1}, 10, 10},
+		{[]int{1, 1}, 10, 15},
+		{[]int{3, 1}, 100, 125},
+		{[]int{70, 25, 5}, 100, 135},
+	}
+	// then
+	for _, test := range tests {
+		result := buildMix(test.weights...).Requests(test.n)
+		if result != test.expected {
+			t.Errorf("got %v expected %v for weights %v", result, test.expected, test.weights)
+		}
+	}
+}
+
+func TestMixChoose(t *testing.T) {
+	// given
+	mix, err := buildMix(70, 25, 5).Compile()
+	if err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	counts := make(map[string]int)
+	// when
+	for i := 0; i < 10000; i++ {
+		_, name := mix.choose()
+		counts[name]++
+	}
+	// then
+	var expected = []struct {
+		name string
+		min  int
+		max  int
+	}{
+		{"a", 6500, 7500},
+		{"b", 2000, 3000},
+		{"c", 250, 750},
+	}
+
+	for _, test := range expected {
+		if counts[test.name] < test.min || counts[test.name] > test.max {
+			t.Errorf("got %v for %v expected between %v and %v", counts[test.name], test.name, test.min, test.max)
+		}
+	}
+}
+
+func TestMixCompileInvalid(t *testing.T) {
+	// given
+	var tests = []*Mix{
+		{},
+		buildMix(1, 0),
+		{Entries: []MixEntry{{Weight: 1}}},
+	}
+	// then
+	for i, test := range tests {
+		if _, err := test.Compile(); err == nil {
+			t.Errorf("expected error for mix %v", i)
+		}
+	}
+}
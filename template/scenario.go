@@ -0,0 +1,117 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import "fmt"
+
+// Scenario is a sequence of requests executed in order by each concurrent request,
+// values extracted from the responses can be used by the templates of the following steps
+type Scenario struct {
+	Steps []Step `json:"steps" yaml:"steps"`
+}
+
+// Step is a request of a scenario
+type Step struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Template file, relative to the scenario file
+	TemplateFile string    `json:"template" yaml:"template"`
+	Extract      []Extract `json:"extract,omitempty" yaml:"extract,omitempty"`
+	// Template read from TemplateFile
+	Request *Template `json:"-" yaml:"-"`
+}
+
+// NewScenario creates a scenario with a single step
+func NewScenario(tmpl *Template) *Scenario {
+	return &Scenario{
+		Steps: []Step{{Request: tmpl}},
+	}
+}
+
+// Compile returns a compiled version of the scenario
+func (s *Scenario) Compile() (*CompiledScenario, error) {
+	if len(s.Steps) == 0 {
+		return nil, fmt.Errorf("scenario without steps")
+	}
+
+	steps := make([]compiledStep, 0, len(s.Steps))
+
+	for i, step := range s.Steps {
+		if step.Request == nil {
+			return nil, fmt.Errorf("step %v without template", i+1)
+		}
+
+		tmplc, err := step.Request.Compile()
+		if err != nil {
+			return nil, fmt.Errorf("step %v: %v", i+1, err)
+		}
+
+		extractors := make([]*extractor, 0, len(step.Extract))
+		for _, extract := range step.Extract {
+			ext, err := extract.compile()
+			if err != nil {
+				return nil, fmt.Errorf("step %v: %v", i+1, err)
+			}
+
+			extractors = append(extractors, ext)
+		}
+
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprint(i + 1)
+		}
+
+		steps = append(steps, compiledStep{
+			name:       name,
+			template:   tmplc,
+			extractors: extractors,
+		})
+	}
+
+	return &CompiledScenario{steps: steps}, nil
+}
+
+// Executable is implemented by CompiledTemplate, CompiledScenario and CompiledMix,
+// it is used by Generator to create requests
+type Executable interface {
+	// choose returns the scenario executed by a generator and its name
+	choose() (*CompiledScenario, string)
+}
+
+type compiledStep struct {
+	name       string
+	template   *CompiledTemplate
+	extractors []*extractor
+}
+
+// CompiledScenario is the compiled version of a Scenario
+type CompiledScenario struct {
+	steps []compiledStep
+}
+
+// Steps returns the number of steps of the scenario
+func (s *CompiledScenario) Steps() int {
+	return len(s.steps)
+}
+
+func (s *CompiledScenario) choose() (*CompiledScenario, string) {
+	return s, ""
+}
+
+// A template is a scenario with one step
+func (c *CompiledTemplate) choose() (*CompiledScenario, string) {
+	return &CompiledScenario{steps: []compiledStep{{name: "1", template: c}}}, ""
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2019-20 Joaquim Rocha <jrocha@gmailbox.org> and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import (
+	"testing"
+
+	"github.com/jjmrocha/beast/client"
+	"github.com/jjmrocha/beast/data"
+)
+
+func TestReadScenario(t *testing.T) {
+	// when
+	scenario, err := ReadScenario("../testdata/scenario.yaml")
+	// then
+	if err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	if len(scenario.Steps) != 2 {
+		t.Fatalf("got %v steps expected 2", len(scenario.Steps))
+	}
+
+	if scenario.Steps[0].Request.Method != "POST" || scenario.Steps[1].Request.Method != "GET" {
+		t.Errorf("got %v and %v expected POST and GET", scenario.Steps[0].Request.Method, scenario.Steps[1].Request.Method)
+	}
+
+	if len(scenario.Steps[0].Extract) != 1 || scenario.Steps[0].Extract[0].Variable != "token" {
+		t.Errorf("unexpected extract %v", scenario.Steps[0].Extract)
+	}
+}
+
+func TestScenarioGenerator(t *testing.T) {
+	// given
+	dt, _ := data.Read("../testdata/data.csv")
+	scenario := &Scenario{
+		Steps: []Step{
+			{
+				Request: &Template{Method: "POST", Endpoint: "http://localhost/login/{{ .Data.A }}"},
+				Extract: []Extract{{"token", FromJSON, "token"}},
+			},
+			{
+				Request: &Template{Method: "GET", Endpoint: "http://localhost/items?token={{ .Vars.token }}"},
+			},
+		},
+	}
+	compiled, err := scenario.Compile()
+	if err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	gnt := &Generator{
+		Data:     dt.Next(),
+		RecordID: 1,
+		Template: compiled,
+	}
+	// when
+	first, _ := gnt.Request()
+	second, err := gnt.Next(&client.Response{StatusCode: 200, Body: []byte(`{"token": "t1"}`)})
+	if err != nil {
+		t.Fatalf("Error not expected: %v", err)
+	}
+
+	last, _ := gnt.Next(&client.Response{StatusCode: 200})
+	// then
+	if first.String() != "POST http://localhost/login/a1" || !first.Capture {
+		t.Errorf("got %v expected POST http://localhost/login/a1 with capture", first)
+	}
+
+	if second.String() != "GET http://localhost/items?token=t1" || second.Capture {
+		t.Errorf("got %v expected GET http://localhost/items?token=t1 without capture", second)
+	}
+
+	if last != nil {
+		t.Errorf("got %v expected no more requests", last)
+	}
+}
+
+func TestScenarioGeneratorStopsOnFailure(t *testing.T) {
+	// given
+	scenario := &Scenario{
+		Steps: []Step{
+			{Request: &Template{Method: "GET", Endpoint: "http://localhost/a"}},
+			{Request: &Template{Method: "GET", Endpoint: "http://localhost/b"}},
+		},
+	}
+	compiled, _ := scenario.Compile()
+	gnt := &Generator{RecordID: 1, Template: compiled}
+	// when
+	gnt.Request()
+	next, err := gnt.Next(&client.Response{StatusCode: 500})
+	// then
+	if next != nil || err != nil {
+		t.Errorf("got %v (%v) expected no more requests", next, err)
+	}
+}
@@ -38,9 +38,11 @@ type Template struct {
 	Endpoint string   `json:"url"`
 	Headers  []Header `json:"headers,omitempty"`
 	Body     string   `json:"body,omitempty"`
+	Checks   *Checks  `json:"checks,omitempty"`
 }
 
-func (t *Template) request() (*client.Request, error) {
+// Request creates the request described by the template, without executing it as a Go template
+func (t *Template) Request() (*client.Request, error) {
 	req, err := http.NewRequest(t.Method, t.Endpoint, bodyReader(t.Body))
 	if err != nil {
 		return nil, err
@@ -89,11 +91,17 @@ func (t *Template) Compile() (*CompiledTemplate, error) {
 		}
 	}
 
+	checks, err := t.Checks.compile()
+	if err != nil {
+		return nil, err
+	}
+
 	tmplc := CompiledTemplate{
 		method:   t.Method,
 		endpoint: tEndpoint,
 		headers:  tHeaders,
 		body:     tBody,
+		checks:   checks,
 	}
 	return &tmplc, nil
 }